@@ -25,5 +25,7 @@ func main() {
 		cancel()
 	}()
 
-	a.Run(ctx)
+	if err := a.Run(ctx); err != nil {
+		logx.Fatal("app run err: %s", err)
+	}
 }
@@ -20,15 +20,26 @@ const (
 )
 
 type Config struct {
-	Env Environment    `yaml:"environment"`
-	TG  TGBotConfig    `yaml:"telegram"`
-	PG  PostgresConfig `yaml:"postgres"`
-	App AppConfig      `yaml:"app"`
+	Env     Environment    `yaml:"environment"`
+	TG      TGBotConfig    `yaml:"telegram"`
+	PG      PostgresConfig `yaml:"postgres"`
+	App     AppConfig      `yaml:"app"`
+	Storage StorageConfig  `yaml:"storage"`
 }
 
 type TGBotConfig struct {
 	Token         string        `yaml:"token"`
+	Mode          string        `yaml:"mode"`
 	PollerTimeout time.Duration `yaml:"poller_timeout"`
+	Webhook       WebhookConfig `yaml:"webhook"`
+}
+
+type WebhookConfig struct {
+	URL         string `yaml:"url"`
+	Listen      string `yaml:"listen"`
+	TLSCert     string `yaml:"tls_cert"`
+	TLSKey      string `yaml:"tls_key"`
+	SecretToken string `yaml:"secret_token"`
 }
 
 type PostgresConfig struct {
@@ -50,6 +61,18 @@ type PostgresConfig struct {
 type AppConfig struct {
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
 	RequestTimeout  time.Duration `yaml:"request_timeout"`
+	TagMode         string        `yaml:"tag_mode"`
+	HealthAddr      string        `yaml:"health_addr"`
+}
+
+type StorageConfig struct {
+	Driver    string `yaml:"driver"`
+	Dir       string `yaml:"dir"`
+	Endpoint  string `yaml:"endpoint"`
+	Bucket    string `yaml:"bucket"`
+	Region    string `yaml:"region"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
 }
 
 func New(file string) (*Config, error) {
@@ -113,6 +136,13 @@ func loadFromEnv(cfg *Config) (*Config, error) {
 	}
 	cfg.TG.Token = botToken
 
+	cfg.TG.Mode = os.Getenv("BOT_MODE")
+	cfg.TG.Webhook.URL = os.Getenv("WEBHOOK_URL")
+	cfg.TG.Webhook.Listen = os.Getenv("WEBHOOK_LISTEN")
+	cfg.TG.Webhook.TLSCert = os.Getenv("WEBHOOK_TLS_CERT")
+	cfg.TG.Webhook.TLSKey = os.Getenv("WEBHOOK_TLS_KEY")
+	cfg.TG.Webhook.SecretToken = os.Getenv("WEBHOOK_SECRET_TOKEN")
+
 	host := os.Getenv("DB_HOST")
 	portStr := os.Getenv("DB_PORT")
 	user := os.Getenv("DB_USER")
@@ -136,6 +166,17 @@ func loadFromEnv(cfg *Config) (*Config, error) {
 	cfg.PG.Name = dbName
 	cfg.PG.SSLMode = sslmode
 
+	cfg.App.TagMode = os.Getenv("TAG_MODE")
+	cfg.App.HealthAddr = os.Getenv("HEALTH_ADDR")
+
+	cfg.Storage.Driver = os.Getenv("STORAGE_DRIVER")
+	cfg.Storage.Dir = os.Getenv("STORAGE_DIR")
+	cfg.Storage.Endpoint = os.Getenv("S3_ENDPOINT")
+	cfg.Storage.Bucket = os.Getenv("S3_BUCKET")
+	cfg.Storage.Region = os.Getenv("S3_REGION")
+	cfg.Storage.AccessKey = os.Getenv("S3_ACCESS_KEY")
+	cfg.Storage.SecretKey = os.Getenv("S3_SECRET_KEY")
+
 	setDefaults(cfg)
 
 	cfg.PG.URL = fmt.Sprintf(
@@ -151,9 +192,15 @@ func setDefaults(cfg *Config) {
 		cfg.Env = EnvDevelopment
 	}
 
+	if cfg.TG.Mode == "" {
+		cfg.TG.Mode = constants.DefaultBotMode
+	}
 	if cfg.TG.PollerTimeout == 0 {
 		cfg.TG.PollerTimeout = constants.BotPollerTimeout
 	}
+	if cfg.TG.Webhook.Listen == "" {
+		cfg.TG.Webhook.Listen = constants.DefaultWebhookListen
+	}
 
 	if cfg.PG.MaxConns == 0 {
 		cfg.PG.MaxConns = constants.DBMaxConns
@@ -180,4 +227,17 @@ func setDefaults(cfg *Config) {
 	if cfg.App.RequestTimeout == 0 {
 		cfg.App.RequestTimeout = constants.DefaultRequestTimeout
 	}
+	if cfg.App.TagMode == "" {
+		cfg.App.TagMode = constants.DefaultTagMode
+	}
+
+	if cfg.Storage.Driver == "" {
+		cfg.Storage.Driver = constants.DefaultStorageDriver
+	}
+	if cfg.Storage.Dir == "" {
+		cfg.Storage.Dir = constants.DefaultStorageDir
+	}
+	if cfg.Storage.Region == "" {
+		cfg.Storage.Region = constants.DefaultS3Region
+	}
 }
@@ -2,17 +2,26 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"picstagsbot/config"
+	"picstagsbot/internal/health"
 	"picstagsbot/internal/postgres"
+	"picstagsbot/internal/postgres/migrate"
 	"picstagsbot/internal/postgres/repoimpl"
 	"picstagsbot/internal/service"
+	"picstagsbot/internal/storage"
 	"picstagsbot/internal/tg/bot"
 	"picstagsbot/internal/tg/handler"
 	"picstagsbot/internal/tg/router"
 	"picstagsbot/pkg/logx"
+	"picstagsbot/pkg/metrics"
 	"picstagsbot/pkg/middleware"
+	"picstagsbot/pkg/validator"
 	"sync"
 	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
 type App struct {
@@ -20,6 +29,9 @@ type App struct {
 	pg          *postgres.Postgres
 	router      *router.Router
 	rateLimiter *middleware.RateLimiter
+	archive     *service.ArchiveService
+	export      *handler.ExportHandler
+	health      *health.Server
 	cfg         *config.Config
 	wg          sync.WaitGroup
 }
@@ -33,6 +45,11 @@ func New(ctx context.Context) (*App, error) {
 	}
 	a.cfg = cfg
 
+	tagMode, err := validator.ParseTagMode(cfg.App.TagMode)
+	if err != nil {
+		return nil, err
+	}
+
 	pgConfig := postgres.Config{
 		URL:             cfg.PG.URL,
 		MaxConns:        int32(cfg.PG.MaxConns),
@@ -48,39 +65,108 @@ func New(ctx context.Context) (*App, error) {
 	}
 	a.pg = pg
 
-	repo := repoimpl.New(pg.Pool)
-	svc := service.New(repo)
-	h := handler.New(svc)
+	store, err := storage.New(storage.Config{
+		Driver:    cfg.Storage.Driver,
+		Dir:       cfg.Storage.Dir,
+		Endpoint:  cfg.Storage.Endpoint,
+		Bucket:    cfg.Storage.Bucket,
+		Region:    cfg.Storage.Region,
+		AccessKey: cfg.Storage.AccessKey,
+		SecretKey: cfg.Storage.SecretKey,
+	})
+	if err != nil {
+		pg.Stop()
+		return nil, err
+	}
 
-	b, err := bot.New(cfg.TG.Token, cfg.TG.PollerTimeout)
+	b, err := bot.New(bot.Config{
+		Token:         cfg.TG.Token,
+		Mode:          cfg.TG.Mode,
+		PollerTimeout: cfg.TG.PollerTimeout,
+		WebhookURL:    cfg.TG.Webhook.URL,
+		WebhookListen: cfg.TG.Webhook.Listen,
+		TLSCert:       cfg.TG.Webhook.TLSCert,
+		TLSKey:        cfg.TG.Webhook.TLSKey,
+		SecretToken:   cfg.TG.Webhook.SecretToken,
+	})
 	if err != nil {
 		pg.Stop()
 		return nil, err
 	}
 	a.bot = b
 
+	repo := repoimpl.New(pg.Pool)
+	svc := service.New(repo, store, b, tagMode)
+	a.archive = svc.Archive
+	h := handler.New(svc)
+	a.export = h.Export
+
 	rateLimiter := middleware.NewRateLimiter(20, 1*time.Minute)
 	a.rateLimiter = rateLimiter
 
 	r := router.New(b.Bot(), h, rateLimiter)
 	a.router = r
 
+	metrics.Register(metrics.NewPoolCollector(pg.Pool))
+	metrics.RegisterSessions("upload", h.Upload.ActiveSessions)
+	metrics.RegisterSessions("search", h.Search.ActiveSessions)
+
+	if cfg.App.HealthAddr != "" {
+		a.health = health.New(cfg.App.HealthAddr, a.readinessChecks()...)
+		a.health.Handle("GET /metrics", metrics.Handler())
+	} else {
+		logx.Warn("health_addr is empty, health checks and metrics are disabled")
+	}
+
 	logx.Info("app initialized", "environment", cfg.Env)
 
 	return a, nil
 }
 
-func (a *App) Run(ctx context.Context) {
+// Run serves until ctx is done, or returns the error that made the bot
+// unable to receive updates.
+func (a *App) Run(ctx context.Context) error {
 	logx.Info("app starting...")
 
+	if err := a.bot.SetWebhook(); err != nil {
+		// The bot has not started yet, so there is nothing to stop but
+		// the pool.
+		a.pg.Stop()
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	a.wg.Add(1)
 	go func() {
 		defer a.wg.Done()
 		a.bot.Start()
 	}()
 
-	<-ctx.Done()
+	if a.health != nil {
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.health.Start()
+		}()
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.archive.Run(ctx)
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-a.bot.Errors():
+		cancel()
+	}
+
 	a.Stop()
+	return err
 }
 
 func (a *App) Stop() {
@@ -92,8 +178,19 @@ func (a *App) Stop() {
 	done := make(chan struct{})
 
 	go func() {
+		if a.health != nil {
+			a.health.Shutdown(shutdownCtx)
+		}
+
+		if a.export != nil {
+			a.export.Stop(shutdownCtx)
+		}
+
 		if a.bot != nil {
-			a.bot.Stop()
+			if err := a.bot.DeleteWebhook(); err != nil {
+				logx.Warn("failed to delete webhook", "error", err)
+			}
+			a.bot.Stop(shutdownCtx)
 		}
 
 		a.wg.Wait()
@@ -112,3 +209,38 @@ func (a *App) Stop() {
 		logx.Warn("app shutdown timeout exceeded, forcing stop")
 	}
 }
+
+// readinessChecks reports the app ready once the database answers, the bot
+// receives updates and the schema is at the newest migration.
+func (a *App) readinessChecks() []health.Check {
+	migrator := migrate.New()
+	db := stdlib.OpenDBFromPool(a.pg.Pool)
+
+	latest, latestErr := migrator.Latest()
+	if latestErr != nil {
+		logx.Warn("failed to read migrations", "error", latestErr)
+	}
+
+	return []health.Check{
+		{Name: "postgres", Check: a.pg.Pool.Ping},
+		{Name: "poller", Check: func(ctx context.Context) error {
+			if !a.bot.Running() {
+				return errors.New("bot is not receiving updates")
+			}
+			return nil
+		}},
+		{Name: "migrations", Check: func(ctx context.Context) error {
+			if latestErr != nil {
+				return latestErr
+			}
+			current, err := migrator.Current(ctx, db)
+			if err != nil {
+				return err
+			}
+			if current < latest {
+				return fmt.Errorf("database is at version %d, latest is %d", current, latest)
+			}
+			return nil
+		}},
+	}
+}
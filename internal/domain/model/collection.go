@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+type Collection struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	PhotoCount int
+	CreatedAt  time.Time
+}
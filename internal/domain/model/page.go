@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Cursor points at the last photo of a page. Rank is only set for ranked
+// (full-text) results.
+type Cursor struct {
+	Rank      float32
+	CreatedAt time.Time
+	ID        int64
+}
+
+type Page struct {
+	After *Cursor
+	Limit int
+}
+
+type PhotoPage struct {
+	Photos []*Photo
+	Next   *Cursor
+}
@@ -6,6 +6,9 @@ type Photo struct {
 	ID          int64
 	UserID      int64
 	TelegramID  string
+	UniqueID    string
+	StorageKey  string
+	Checksum    string
 	FileSize    int64
 	Width       int
 	Height      int
@@ -13,3 +16,10 @@ type Photo struct {
 	Tags        []string
 	CreatedAt   time.Time
 }
+
+// PhotoEdit replaces the description and the tags of a photo.
+type PhotoEdit struct {
+	PhotoID     int64
+	Description string
+	Tags        []string
+}
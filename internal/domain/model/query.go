@@ -0,0 +1,9 @@
+package model
+
+// TagQuery is a parsed boolean tag search. A photo matches when it has every
+// tag from All, at least one tag from each group in Any and none from Exclude.
+type TagQuery struct {
+	All     []string
+	Any     [][]string
+	Exclude []string
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Share grants a single recipient read-only access to the owner's photos with
+// one tag. RecipientID is zero until somebody opens the link.
+type Share struct {
+	ID          int64
+	Token       string
+	OwnerID     int64
+	OwnerName   string
+	Tag         string
+	RecipientID int64
+	CreatedAt   time.Time
+	ExpiresAt   *time.Time
+	RevokedAt   *time.Time
+}
+
+func (s *Share) IsActive(now time.Time) bool {
+	if s.RevokedAt != nil {
+		return false
+	}
+	return s.ExpiresAt == nil || now.Before(*s.ExpiresAt)
+}
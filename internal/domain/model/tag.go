@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+type TagStat struct {
+	Name     string
+	Count    int
+	LastUsed time.Time
+}
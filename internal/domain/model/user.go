@@ -2,9 +2,17 @@ package model
 
 import "time"
 
+// A user of kind UserKindGroup is a group chat that owns the library shared
+// by its members. Its TelegramID is the chat id.
+const (
+	UserKindPrivate = "private"
+	UserKindGroup   = "group"
+)
+
 type User struct {
 	ID         int64     `db:"id"`
 	TelegramID int64     `db:"telegram_id"`
 	Username   string    `db:"username"`
+	Kind       string    `db:"kind"`
 	CreatedAt  time.Time `db:"created_at"`
 }
@@ -0,0 +1,17 @@
+package repo
+
+import (
+	"context"
+	"picstagsbot/internal/domain/model"
+)
+
+type CollectionRepo interface {
+	Create(ctx context.Context, collection *model.Collection) error
+	GetByID(ctx context.Context, collectionID int64) (*model.Collection, error)
+	GetByName(ctx context.Context, userID int64, name string) (*model.Collection, error)
+	ListByUser(ctx context.Context, userID int64) ([]*model.Collection, error)
+	Rename(ctx context.Context, userID, collectionID int64, name string) (bool, error)
+	Delete(ctx context.Context, userID, collectionID int64) (bool, error)
+	AddPhotos(ctx context.Context, collectionID int64, photoIDs []int64) (int, error)
+	ListPhotos(ctx context.Context, collectionID int64, page model.Page) (*model.PhotoPage, error)
+}
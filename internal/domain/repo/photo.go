@@ -6,8 +6,24 @@ import (
 )
 
 type PhotoRepo interface {
-	Create(ctx context.Context, photo *model.Photo) error
-	GetByFileID(ctx context.Context, fileID string) (*model.Photo, error)
+	Create(ctx context.Context, photo *model.Photo) (bool, error)
+	GetByID(ctx context.Context, photoID int64) (*model.Photo, error)
+	GetByUniqueID(ctx context.Context, userID int64, uniqueID, fileID string) (*model.Photo, error)
+	SetStorage(ctx context.Context, photoID int64, key, checksum string) error
+	UpdateFileID(ctx context.Context, photoID int64, fileID string) error
+	ListByUser(ctx context.Context, userID int64, page model.Page) (*model.PhotoPage, error)
+	ListUnarchived(ctx context.Context, afterID int64, limit int) ([]*model.Photo, error)
+	ListWithoutUniqueID(ctx context.Context, afterID int64, limit int) ([]*model.Photo, error)
+	MarkFileFailed(ctx context.Context, photoID int64) error
+	SetUniqueID(ctx context.Context, photo *model.Photo, uniqueID string) (*model.Photo, error)
+	ApplyEdits(ctx context.Context, userID int64, edits []model.PhotoEdit) (int, error)
 	UpdateDescription(ctx context.Context, photoID int64, description string, tags []string) error
-	SearchByTag(ctx context.Context, userID int64, tag string) ([]*model.Photo, error)
+	SearchByTag(ctx context.Context, userID int64, tag string, page model.Page) (*model.PhotoPage, error)
+	SearchByQuery(ctx context.Context, userID int64, q *model.TagQuery, page model.Page) (*model.PhotoPage, error)
+	CountByQuery(ctx context.Context, userID int64, q *model.TagQuery) (int, error)
+	SearchText(ctx context.Context, userID int64, text string, page model.Page) (*model.PhotoPage, error)
+	CountText(ctx context.Context, userID int64, text string) (int, error)
+	TagStats(ctx context.Context, userID int64, limit int) ([]*model.TagStat, error)
+	SuggestTags(ctx context.Context, userID int64, prefix string, limit int) ([]string, error)
+	Delete(ctx context.Context, userID, photoID int64) (bool, error)
 }
@@ -1,6 +1,14 @@
 package repo
 
+import "errors"
+
+// ErrDuplicate is returned when a write hits a unique constraint, for example
+// a second collection with the same name.
+var ErrDuplicate = errors.New("duplicate key")
+
 type Repo struct {
-	UserRepo  UserRepo
-	PhotoRepo PhotoRepo
+	UserRepo       UserRepo
+	PhotoRepo      PhotoRepo
+	CollectionRepo CollectionRepo
+	ShareRepo      ShareRepo
 }
@@ -0,0 +1,16 @@
+package repo
+
+import (
+	"context"
+	"picstagsbot/internal/domain/model"
+)
+
+type ShareRepo interface {
+	Create(ctx context.Context, share *model.Share) (bool, error)
+	GetByID(ctx context.Context, shareID int64) (*model.Share, error)
+	GetByToken(ctx context.Context, token string) (*model.Share, error)
+	Claim(ctx context.Context, shareID, recipientID int64) (bool, error)
+	Revoke(ctx context.Context, ownerID, shareID int64) (bool, error)
+	ListByOwner(ctx context.Context, ownerID int64) ([]*model.Share, error)
+	ListByRecipient(ctx context.Context, recipientID int64) ([]*model.Share, error)
+}
@@ -6,6 +6,6 @@ import (
 )
 
 type UserRepo interface {
-	Create(ctx context.Context, botuser *model.User) error
+	Create(ctx context.Context, botuser *model.User) (bool, error)
 	GetByTelegramID(ctx context.Context, telegramID int64) (*model.User, error)
 }
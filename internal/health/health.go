@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"picstagsbot/pkg/constants"
+	"picstagsbot/pkg/logx"
+)
+
+// Check reports why a dependency is not ready, or nil when it is.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Server answers /healthz while the process is alive and /readyz when all
+// the checks pass.
+type Server struct {
+	server *http.Server
+	mux    *http.ServeMux
+	checks []Check
+}
+
+type readyResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func New(addr string, checks ...Check) *Server {
+	s := &Server{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /readyz", s.handleReady)
+
+	s.mux = mux
+	s.checks = checks
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: constants.HealthCheckTimeout,
+		WriteTimeout:      constants.HealthCheckTimeout * 2,
+	}
+
+	return s
+}
+
+// Handle serves an extra endpoint, such as /metrics, next to the checks. It
+// must be called before Start.
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+// Start serves until Shutdown is called.
+func (s *Server) Start() {
+	logx.Info("health server listening", "addr", s.server.Addr)
+
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logx.Error("health server failed", "error", err)
+	}
+}
+
+func (s *Server) Shutdown(ctx context.Context) {
+	if err := s.server.Shutdown(ctx); err != nil {
+		logx.Warn("health server shutdown failed", "error", err)
+		return
+	}
+	logx.Info("health server stopped")
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), constants.HealthCheckTimeout)
+	defer cancel()
+
+	resp := readyResponse{Status: "ok", Checks: make(map[string]string, len(s.checks))}
+	status := http.StatusOK
+
+	for _, c := range s.checks {
+		if err := c.Check(ctx); err != nil {
+			logx.Warn("readiness check failed", "check", c.Name, "error", err)
+			resp.Checks[c.Name] = err.Error()
+			resp.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		resp.Checks[c.Name] = "ok"
+	}
+
+	writeJSON(w, status, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
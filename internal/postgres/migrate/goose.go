@@ -1,18 +1,25 @@
 package migrate
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"picstagsbot/migrations"
 	"picstagsbot/pkg/logx"
 
 	"github.com/pressly/goose/v3"
 )
 
+// dir is the root of the embedded migrations.FS.
+const dir = "."
+
 type Goose struct{}
 
 func New() *Goose {
 	g := &Goose{}
 
 	goose.SetLogger(goose.NopLogger())
+	goose.SetBaseFS(migrations.FS)
 
 	logx.Info("goose migrator initialized")
 
@@ -24,7 +31,7 @@ func (g *Goose) Up(db *sql.DB) error {
 		return err
 	}
 
-	if err := goose.Up(db, "./migrations"); err != nil {
+	if err := goose.Up(db, dir); err != nil {
 		return err
 	}
 
@@ -32,3 +39,46 @@ func (g *Goose) Up(db *sql.DB) error {
 
 	return nil
 }
+
+// Latest returns the version of the newest migration on disk.
+func (g *Goose) Latest() (int64, error) {
+	migrations, err := goose.CollectMigrations(dir, 0, goose.MaxVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	last, err := migrations.Last()
+	if err != nil {
+		return 0, err
+	}
+	return last.Version, nil
+}
+
+// Current returns the version the database is migrated to. Unlike
+// goose.GetDBVersion it only reads, so it is safe to call from a health
+// check: the newest record of a version tells whether it is applied.
+func (g *Goose) Current(ctx context.Context, db *sql.DB) (int64, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT version_id, is_applied FROM %s ORDER BY id DESC", goose.TableName()))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	skipped := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		var applied bool
+		if err := rows.Scan(&version, &applied); err != nil {
+			return 0, err
+		}
+		if skipped[version] {
+			continue
+		}
+		if applied {
+			return version, nil
+		}
+		skipped[version] = true
+	}
+
+	return 0, rows.Err()
+}
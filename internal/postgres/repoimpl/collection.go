@@ -0,0 +1,216 @@
+package repoimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/internal/domain/repo"
+	"picstagsbot/pkg/logx"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// collectionColumns selects a collection row aliased as c together with the
+// number of photos in it.
+const collectionColumns = `
+	c.id, c.user_id, c.name,
+	(SELECT COUNT(*) FROM collection_photos cp WHERE cp.collection_id = c.id) AS photo_count,
+	c.created_at`
+
+type CollectionRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewCollectionRepo(pool *pgxpool.Pool) *CollectionRepo {
+	cr := &CollectionRepo{}
+
+	cr.pool = pool
+
+	return cr
+}
+
+func (r *CollectionRepo) Create(ctx context.Context, collection *model.Collection) error {
+	query := `INSERT INTO collections (user_id, name, created_at) VALUES ($1, $2, $3) RETURNING id`
+
+	err := r.pool.QueryRow(ctx, query, collection.UserID, collection.Name, collection.CreatedAt).Scan(&collection.ID)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("collection %q: %w", collection.Name, repo.ErrDuplicate)
+	}
+	if err != nil {
+		logx.Error("db: failed to create collection", "user_id", collection.UserID, "name", collection.Name, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *CollectionRepo) GetByID(ctx context.Context, collectionID int64) (*model.Collection, error) {
+	query := `SELECT ` + collectionColumns + ` FROM collections c WHERE c.id = $1`
+
+	collection, err := scanCollection(r.pool.QueryRow(ctx, query, collectionID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		logx.Error("db: failed to get collection by id", "collection_id", collectionID, "error", err)
+		return nil, err
+	}
+
+	return collection, nil
+}
+
+func (r *CollectionRepo) GetByName(ctx context.Context, userID int64, name string) (*model.Collection, error) {
+	query := `SELECT ` + collectionColumns + ` FROM collections c WHERE c.user_id = $1 AND c.name = $2`
+
+	collection, err := scanCollection(r.pool.QueryRow(ctx, query, userID, name))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		logx.Error("db: failed to get collection by name", "user_id", userID, "name", name, "error", err)
+		return nil, err
+	}
+
+	return collection, nil
+}
+
+func (r *CollectionRepo) ListByUser(ctx context.Context, userID int64) ([]*model.Collection, error) {
+	query := `SELECT ` + collectionColumns + ` FROM collections c WHERE c.user_id = $1 ORDER BY c.name, c.id`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		logx.Error("db: failed to list collections", "user_id", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var collections []*model.Collection
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			logx.Error("db: failed to scan collection row", "user_id", userID, "error", err)
+			return nil, err
+		}
+		collections = append(collections, collection)
+	}
+
+	if err := rows.Err(); err != nil {
+		logx.Error("db: error iterating collection rows", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+func (r *CollectionRepo) Rename(ctx context.Context, userID, collectionID int64, name string) (bool, error) {
+	query := `
+		UPDATE collections
+		SET name = $1
+		WHERE id = $2 AND user_id = $3
+	`
+
+	cmd, err := r.pool.Exec(ctx, query, name, collectionID, userID)
+	if isUniqueViolation(err) {
+		return false, fmt.Errorf("collection %q: %w", name, repo.ErrDuplicate)
+	}
+	if err != nil {
+		logx.Error("db: failed to rename collection", "user_id", userID, "collection_id", collectionID, "error", err)
+		return false, err
+	}
+
+	return cmd.RowsAffected() > 0, nil
+}
+
+func (r *CollectionRepo) Delete(ctx context.Context, userID, collectionID int64) (bool, error) {
+	query := `
+		DELETE FROM collections
+		WHERE id = $1 AND user_id = $2
+	`
+
+	cmd, err := r.pool.Exec(ctx, query, collectionID, userID)
+	if err != nil {
+		logx.Error("db: failed to delete collection", "user_id", userID, "collection_id", collectionID, "error", err)
+		return false, err
+	}
+
+	return cmd.RowsAffected() > 0, nil
+}
+
+// AddPhotos links the photos to the collection and returns how many of them
+// were not in it yet. Photos owned by another user are ignored.
+func (r *CollectionRepo) AddPhotos(ctx context.Context, collectionID int64, photoIDs []int64) (int, error) {
+	query := `
+		INSERT INTO collection_photos (collection_id, photo_id)
+		SELECT c.id, p.id
+		FROM collections c
+		JOIN photos p ON p.user_id = c.user_id
+		WHERE c.id = $1 AND p.id = ANY($2::bigint[])
+		ON CONFLICT DO NOTHING
+	`
+
+	cmd, err := r.pool.Exec(ctx, query, collectionID, photoIDs)
+	if err != nil {
+		logx.Error("db: failed to add photos to collection", "collection_id", collectionID, "photos_count", len(photoIDs), "error", err)
+		return 0, err
+	}
+
+	return int(cmd.RowsAffected()), nil
+}
+
+func (r *CollectionRepo) ListPhotos(ctx context.Context, collectionID int64, page model.Page) (*model.PhotoPage, error) {
+	args := []any{collectionID}
+	cond := ""
+
+	if page.After != nil {
+		args = append(args, page.After.CreatedAt, page.After.ID)
+		cond = fmt.Sprintf("AND (p.created_at, p.id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	args = append(args, page.Limit+1)
+	query := fmt.Sprintf(`
+		SELECT `+photoColumns+`
+		FROM photos p
+		JOIN collection_photos cp ON cp.photo_id = p.id
+		WHERE cp.collection_id = $1 %s
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $%d`, cond, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		logx.Error("db: failed to list collection photos", "collection_id", collectionID, "error", err)
+		return nil, err
+	}
+
+	photos, err := collectPhotos(rows)
+	if err != nil {
+		logx.Error("db: failed to read collection photos", "collection_id", collectionID, "error", err)
+		return nil, err
+	}
+
+	result := &model.PhotoPage{Photos: photos}
+	if len(photos) > page.Limit {
+		result.Photos = photos[:page.Limit]
+		last := result.Photos[page.Limit-1]
+		result.Next = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return result, nil
+}
+
+func scanCollection(row pgx.Row) (*model.Collection, error) {
+	collection := &model.Collection{}
+
+	err := row.Scan(
+		&collection.ID,
+		&collection.UserID,
+		&collection.Name,
+		&collection.PhotoCount,
+		&collection.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return collection, nil
+}
@@ -6,11 +6,27 @@ import (
 	"fmt"
 	"picstagsbot/internal/domain/model"
 	"picstagsbot/pkg/logx"
+	"strconv"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// photoColumns selects a photo row aliased as p, collecting its tags from
+// photo_tags into an array.
+const photoColumns = `
+	p.id, p.user_id, p.telegram_id, COALESCE(p.file_unique_id, ''),
+	COALESCE(p.storage_key, ''), COALESCE(p.checksum, ''), p.file_size, p.width, p.height, p.description,
+	ARRAY(
+		SELECT t.name
+		FROM photo_tags pt
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.photo_id = p.id
+		ORDER BY t.name
+	) AS tags,
+	p.created_at`
+
 type PhotoRepo struct {
 	pool *pgxpool.Pool
 }
@@ -23,122 +39,744 @@ func NewPhotoRepo(pool *pgxpool.Pool) *PhotoRepo {
 	return pr
 }
 
-func (r *PhotoRepo) Create(ctx context.Context, photo *model.Photo) error {
+// Create stores the photo unless the user already has it. It reports false
+// and fills photo with the stored row when the photo is a duplicate. A photo
+// stored before file_unique_id was recorded is matched by file_id and gets
+// the unique id backfilled.
+func (r *PhotoRepo) Create(ctx context.Context, photo *model.Photo) (bool, error) {
+	backfillQuery := `
+		UPDATE photos
+		SET file_unique_id = $2
+		WHERE user_id = $1 AND telegram_id = $3 AND file_unique_id IS NULL
+			AND NOT EXISTS (SELECT 1 FROM photos WHERE user_id = $1 AND file_unique_id = $2)
+	`
+
 	query := `
-		INSERT INTO photos (user_id, telegram_id, file_size, width, height, description, tags, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO photos (user_id, telegram_id, file_unique_id, file_size, width, height, description, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, file_unique_id) DO NOTHING
 		RETURNING id
 	`
 
-	err := r.pool.QueryRow(
-		ctx,
-		query,
-		photo.UserID,
-		photo.TelegramID,
-		photo.FileSize,
-		photo.Width,
-		photo.Height,
-		photo.Description,
-		photo.Tags,
-		photo.CreatedAt,
-	).Scan(&photo.ID)
+	existingQuery := `SELECT ` + photoColumns + ` FROM photos p WHERE p.user_id = $1 AND p.file_unique_id = $2`
+
+	created := false
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if photo.UniqueID != "" {
+			if _, err := tx.Exec(ctx, backfillQuery, photo.UserID, photo.UniqueID, photo.TelegramID); err != nil {
+				return err
+			}
+		}
+
+		err := tx.QueryRow(
+			ctx,
+			query,
+			photo.UserID,
+			photo.TelegramID,
+			photo.UniqueID,
+			photo.FileSize,
+			photo.Width,
+			photo.Height,
+			photo.Description,
+			photo.CreatedAt,
+		).Scan(&photo.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			existing, err := scanPhoto(tx.QueryRow(ctx, existingQuery, photo.UserID, photo.UniqueID))
+			if err != nil {
+				return err
+			}
+			*photo = *existing
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		created = true
+		return setPhotoTags(ctx, tx, photo.UserID, photo.ID, photo.Tags)
+	})
 
 	if err != nil {
 		logx.Error("db: failed to create photo", "user_id", photo.UserID, "file_id", photo.TelegramID, "error", err)
+		return false, err
 	}
-	return err
+	return created, nil
+}
+
+func (r *PhotoRepo) GetByID(ctx context.Context, photoID int64) (*model.Photo, error) {
+	query := `SELECT ` + photoColumns + ` FROM photos p WHERE p.id = $1`
+
+	photo, err := scanPhoto(r.pool.QueryRow(ctx, query, photoID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		logx.Error("db: failed to get photo by id", "photo_id", photoID, "error", err)
+		return nil, err
+	}
+
+	return photo, nil
 }
 
-func (r *PhotoRepo) GetByFileID(ctx context.Context, fileID string) (*model.Photo, error) {
+// GetByUniqueID finds the user's photo by its file_unique_id. Photos stored
+// before the column existed have none and are matched by file_id instead.
+func (r *PhotoRepo) GetByUniqueID(ctx context.Context, userID int64, uniqueID, fileID string) (*model.Photo, error) {
 	query := `
-		SELECT id, user_id, telegram_id, file_size, width, height, description, tags, created_at
-		FROM photos
-		WHERE telegram_id = $1
+		SELECT ` + photoColumns + `
+		FROM photos p
+		WHERE p.user_id = $1
+			AND (p.file_unique_id = $2 OR (p.file_unique_id IS NULL AND p.telegram_id = $3))
+		ORDER BY p.file_unique_id NULLS LAST, p.id
+		LIMIT 1
 	`
 
-	photo := &model.Photo{}
-	err := r.pool.QueryRow(ctx, query, fileID).Scan(
-		&photo.ID,
-		&photo.UserID,
-		&photo.TelegramID,
-		&photo.FileSize,
-		&photo.Width,
-		&photo.Height,
-		&photo.Description,
-		&photo.Tags,
-		&photo.CreatedAt,
-	)
-
+	photo, err := scanPhoto(r.pool.QueryRow(ctx, query, userID, uniqueID, fileID))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
-		logx.Error("db: failed to get photo by file_id", "file_id", fileID, "error", err)
+		logx.Error("db: failed to get photo by file_unique_id", "user_id", userID, "file_unique_id", uniqueID, "error", err)
 		return nil, err
 	}
 
 	return photo, nil
 }
 
-func (r *PhotoRepo) UpdateDescription(ctx context.Context, photoID int64, description string, tags []string) error {
+// SetStorage records where the photo's original bytes are kept.
+func (r *PhotoRepo) SetStorage(ctx context.Context, photoID int64, key, checksum string) error {
 	query := `
 		UPDATE photos
-		SET description = $1, tags = $2
+		SET storage_key = $1, checksum = $2, file_failures = 0, file_failed_at = NULL
 		WHERE id = $3
 	`
 
-	cmd, err := r.pool.Exec(ctx, query, description, tags, photoID)
+	if _, err := r.pool.Exec(ctx, query, key, checksum, photoID); err != nil {
+		logx.Error("db: failed to set photo storage", "photo_id", photoID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateFileID replaces a file_id Telegram no longer accepts.
+func (r *PhotoRepo) UpdateFileID(ctx context.Context, photoID int64, fileID string) error {
+	query := `
+		UPDATE photos
+		SET telegram_id = $1, file_failures = 0, file_failed_at = NULL
+		WHERE id = $2
+	`
+
+	if _, err := r.pool.Exec(ctx, query, fileID, photoID); err != nil {
+		logx.Error("db: failed to update photo file_id", "photo_id", photoID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// ListByUser returns a page of all the user's photos, newest first.
+func (r *PhotoRepo) ListByUser(ctx context.Context, userID int64, page model.Page) (*model.PhotoPage, error) {
+	args := []any{userID}
+	cond := ""
+
+	if page.After != nil {
+		args = append(args, page.After.CreatedAt, page.After.ID)
+		cond = fmt.Sprintf("AND (p.created_at, p.id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	args = append(args, page.Limit+1)
+	query := fmt.Sprintf(`
+		SELECT `+photoColumns+`
+		FROM photos p
+		WHERE p.user_id = $1 %s
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $%d`, cond, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		logx.Error("db: failed to list user photos", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	photos, err := collectPhotos(rows)
+	if err != nil {
+		logx.Error("db: failed to read user photos", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	result := &model.PhotoPage{Photos: photos}
+	if len(photos) > page.Limit {
+		result.Photos = photos[:page.Limit]
+		last := result.Photos[page.Limit-1]
+		result.Next = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return result, nil
+}
+
+// fileRetryDue holds for photos whose file_id has not failed recently. After
+// each failure the wait before the next attempt doubles, starting at an hour
+// and capped at 256 hours.
+const fileRetryDue = `
+	(p.file_failed_at IS NULL
+		OR p.file_failed_at + interval '1 hour' * power(2, LEAST(p.file_failures - 1, 8)) <= NOW())`
+
+// ListUnarchived returns photos whose bytes are not in storage yet, in id
+// order starting after afterID. Photos whose file_id keeps failing are only
+// returned once their backoff is over.
+func (r *PhotoRepo) ListUnarchived(ctx context.Context, afterID int64, limit int) ([]*model.Photo, error) {
+	query := `
+		SELECT ` + photoColumns + `
+		FROM photos p
+		WHERE p.storage_key IS NULL AND p.id > $1 AND ` + fileRetryDue + `
+		ORDER BY p.id
+		LIMIT $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, afterID, limit)
 	if err != nil {
-		logx.Error("db: failed to update photo description", "photo_id", photoID, "error", err)
+		logx.Error("db: failed to list unarchived photos", "after_id", afterID, "error", err)
+		return nil, err
+	}
+
+	photos, err := collectPhotos(rows)
+	if err != nil {
+		logx.Error("db: failed to read unarchived photos", "after_id", afterID, "error", err)
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// ListWithoutUniqueID returns photos stored before file_unique_id was
+// recorded, in id order starting after afterID. Like ListUnarchived it skips
+// photos whose file_id failed recently.
+func (r *PhotoRepo) ListWithoutUniqueID(ctx context.Context, afterID int64, limit int) ([]*model.Photo, error) {
+	query := `
+		SELECT ` + photoColumns + `
+		FROM photos p
+		WHERE p.file_unique_id IS NULL AND p.id > $1 AND ` + fileRetryDue + `
+		ORDER BY p.id
+		LIMIT $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, afterID, limit)
+	if err != nil {
+		logx.Error("db: failed to list photos without unique id", "after_id", afterID, "error", err)
+		return nil, err
+	}
+
+	photos, err := collectPhotos(rows)
+	if err != nil {
+		logx.Error("db: failed to read photos without unique id", "after_id", afterID, "error", err)
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// MarkFileFailed records that Telegram did not serve the photo's file_id,
+// which postpones the next attempt to archive or backfill it.
+func (r *PhotoRepo) MarkFileFailed(ctx context.Context, photoID int64) error {
+	query := `
+		UPDATE photos
+		SET file_failures = file_failures + 1, file_failed_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := r.pool.Exec(ctx, query, photoID); err != nil {
+		logx.Error("db: failed to mark photo file failure", "photo_id", photoID, "error", err)
 		return err
 	}
 
-	if cmd.RowsAffected() == 0 {
-		logx.Warn("db: photo not found for update", "photo_id", photoID)
-		return fmt.Errorf("photo with id %d not found", photoID)
+	return nil
+}
+
+// SetUniqueID records the file_unique_id of a legacy photo. When the user
+// already has another row with that id, the two are merged into the older
+// one the same way migration 00010 does, and the removed row is returned so
+// its stored bytes can be deleted.
+func (r *PhotoRepo) SetUniqueID(ctx context.Context, photo *model.Photo, uniqueID string) (*model.Photo, error) {
+	existingQuery := `SELECT ` + photoColumns + ` FROM photos p WHERE p.user_id = $1 AND p.file_unique_id = $2`
+
+	updateQuery := `
+		UPDATE photos
+		SET file_unique_id = $2, file_failures = 0, file_failed_at = NULL
+		WHERE id = $1 AND file_unique_id IS NULL
+	`
+
+	mergeTagsQuery := `
+		INSERT INTO photo_tags (photo_id, tag_id)
+		SELECT $1, tag_id FROM photo_tags WHERE photo_id = $2
+		ON CONFLICT DO NOTHING
+	`
+
+	mergeCollectionsQuery := `
+		INSERT INTO collection_photos (collection_id, photo_id, added_at)
+		SELECT collection_id, $1, added_at FROM collection_photos WHERE photo_id = $2
+		ON CONFLICT DO NOTHING
+	`
+
+	mergeDescriptionQuery := `
+		UPDATE photos
+		SET description = $2
+		WHERE id = $1 AND COALESCE(description, '') = ''
+	`
+
+	deleteQuery := `DELETE FROM photos WHERE id = $1`
+
+	var dropped *model.Photo
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		existing, err := scanPhoto(tx.QueryRow(ctx, existingQuery, photo.UserID, uniqueID))
+		if errors.Is(err, pgx.ErrNoRows) {
+			_, err = tx.Exec(ctx, updateQuery, photo.ID, uniqueID)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		keep, drop := existing, photo
+		if photo.CreatedAt.Before(existing.CreatedAt) {
+			keep, drop = photo, existing
+		}
+
+		if _, err := tx.Exec(ctx, mergeTagsQuery, keep.ID, drop.ID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, mergeCollectionsQuery, keep.ID, drop.ID); err != nil {
+			return err
+		}
+		if drop.Description != "" {
+			if _, err := tx.Exec(ctx, mergeDescriptionQuery, keep.ID, drop.Description); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(ctx, deleteQuery, drop.ID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, updateQuery, keep.ID, uniqueID); err != nil {
+			return err
+		}
+
+		dropped = drop
+		return nil
+	})
+	if err != nil {
+		logx.Error("db: failed to set photo unique id", "photo_id", photo.ID, "error", err)
+		return nil, err
+	}
+
+	return dropped, nil
+}
+
+// ApplyEdits replaces descriptions and tags of the user's photos in a single
+// transaction and returns how many photos were updated. Photos of other
+// users are skipped.
+func (r *PhotoRepo) ApplyEdits(ctx context.Context, userID int64, edits []model.PhotoEdit) (int, error) {
+	query := `
+		UPDATE photos
+		SET description = $1
+		WHERE id = $2 AND user_id = $3
+	`
+
+	updated := 0
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		for _, e := range edits {
+			cmd, err := tx.Exec(ctx, query, e.Description, e.PhotoID, userID)
+			if err != nil {
+				return err
+			}
+			if cmd.RowsAffected() == 0 {
+				continue
+			}
+
+			if _, err := tx.Exec(ctx, `DELETE FROM photo_tags WHERE photo_id = $1`, e.PhotoID); err != nil {
+				return err
+			}
+			if err := setPhotoTags(ctx, tx, userID, e.PhotoID, e.Tags); err != nil {
+				return err
+			}
+			updated++
+		}
+		return deleteUnusedTags(ctx, tx, userID)
+	})
+
+	if err != nil {
+		logx.Error("db: failed to apply photo edits", "user_id", userID, "edits_count", len(edits), "error", err)
+		return 0, err
+	}
+
+	return updated, nil
+}
+
+func (r *PhotoRepo) UpdateDescription(ctx context.Context, photoID int64, description string, tags []string) error {
+	query := `
+		UPDATE photos
+		SET description = $1
+		WHERE id = $2
+		RETURNING user_id
+	`
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var userID int64
+		if err := tx.QueryRow(ctx, query, description, photoID).Scan(&userID); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM photo_tags WHERE photo_id = $1`, photoID); err != nil {
+			return err
+		}
+
+		if err := setPhotoTags(ctx, tx, userID, photoID, tags); err != nil {
+			return err
+		}
+
+		return deleteUnusedTags(ctx, tx, userID)
+	})
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logx.Warn("db: photo not found for update", "photo_id", photoID)
+			return fmt.Errorf("photo with id %d not found", photoID)
+		}
+		logx.Error("db: failed to update photo description", "photo_id", photoID, "error", err)
+		return err
 	}
 
 	return nil
 }
 
-func (r *PhotoRepo) SearchByTag(ctx context.Context, userID int64, tag string) ([]*model.Photo, error) {
+func (r *PhotoRepo) SearchByTag(ctx context.Context, userID int64, tag string, page model.Page) (*model.PhotoPage, error) {
+	return r.SearchByQuery(ctx, userID, &model.TagQuery{All: []string{tag}}, page)
+}
+
+func (r *PhotoRepo) TagStats(ctx context.Context, userID int64, limit int) ([]*model.TagStat, error) {
+	query := `
+		SELECT t.name, COUNT(*), MAX(p.created_at)
+		FROM tags t
+		JOIN photo_tags pt ON pt.tag_id = t.id
+		JOIN photos p ON p.id = pt.photo_id
+		WHERE t.user_id = $1
+		GROUP BY t.id, t.name
+		ORDER BY COUNT(*) DESC, MAX(p.created_at) DESC, t.name
+		LIMIT $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID, limit)
+	if err != nil {
+		logx.Error("db: failed to get tag stats", "user_id", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*model.TagStat
+	for rows.Next() {
+		stat := &model.TagStat{}
+		if err := rows.Scan(&stat.Name, &stat.Count, &stat.LastUsed); err != nil {
+			logx.Error("db: failed to scan tag stat row", "user_id", userID, "error", err)
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	if err := rows.Err(); err != nil {
+		logx.Error("db: error iterating tag stat rows", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (r *PhotoRepo) SearchByQuery(ctx context.Context, userID int64, q *model.TagQuery, page model.Page) (*model.PhotoPage, error) {
+	conds, args := tagQueryConds(userID, q)
+
+	if page.After != nil {
+		args = append(args, page.After.CreatedAt, page.After.ID)
+		conds = append(conds, fmt.Sprintf("(p.created_at, p.id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	args = append(args, page.Limit+1)
+	query := `
+		SELECT ` + photoColumns + `
+		FROM photos p
+		WHERE ` + strings.Join(conds, " AND ") + `
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		logx.Error("db: failed to search photos by query", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	photos, err := collectPhotos(rows)
+	if err != nil {
+		logx.Error("db: failed to read photos by query", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	result := &model.PhotoPage{Photos: photos}
+	if len(photos) > page.Limit {
+		result.Photos = photos[:page.Limit]
+		last := result.Photos[page.Limit-1]
+		result.Next = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return result, nil
+}
+
+func (r *PhotoRepo) CountByQuery(ctx context.Context, userID int64, q *model.TagQuery) (int, error) {
+	conds, args := tagQueryConds(userID, q)
+
+	query := `SELECT COUNT(*) FROM photos p WHERE ` + strings.Join(conds, " AND ")
+
+	var count int
+	if err := r.pool.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		logx.Error("db: failed to count photos by query", "user_id", userID, "error", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *PhotoRepo) SearchText(ctx context.Context, userID int64, text string, page model.Page) (*model.PhotoPage, error) {
+	conds := []string{"p.user_id = $1", "p.search_vector @@ q.query"}
+	args := []any{userID, text}
+
+	if page.After != nil {
+		args = append(args, page.After.Rank, page.After.CreatedAt, page.After.ID)
+		conds = append(conds, fmt.Sprintf(
+			"(ts_rank(p.search_vector, q.query), p.created_at, p.id) < ($%d::real, $%d, $%d)",
+			len(args)-2, len(args)-1, len(args),
+		))
+	}
+
+	args = append(args, page.Limit+1)
 	query := `
-		SELECT id, user_id, telegram_id, file_size, width, height, description, tags, created_at
+		WITH q AS (
+			SELECT websearch_to_tsquery('russian', $2) || websearch_to_tsquery('simple', $2) AS query
+		)
+		SELECT ` + photoColumns + `, ts_rank(p.search_vector, q.query) AS rank
+		FROM photos p, q
+		WHERE ` + strings.Join(conds, " AND ") + `
+		ORDER BY rank DESC, p.created_at DESC, p.id DESC
+		LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		logx.Error("db: failed to search photos by text", "user_id", userID, "text", text, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var photos []*model.Photo
+	var ranks []float32
+	for rows.Next() {
+		var rank float32
+		photo, err := scanPhoto(rows, &rank)
+		if err != nil {
+			logx.Error("db: failed to scan photo row", "user_id", userID, "text", text, "error", err)
+			return nil, err
+		}
+		photos = append(photos, photo)
+		ranks = append(ranks, rank)
+	}
+
+	if err := rows.Err(); err != nil {
+		logx.Error("db: error iterating photo rows", "user_id", userID, "text", text, "error", err)
+		return nil, err
+	}
+
+	result := &model.PhotoPage{Photos: photos}
+	if len(photos) > page.Limit {
+		result.Photos = photos[:page.Limit]
+		last := result.Photos[page.Limit-1]
+		result.Next = &model.Cursor{Rank: ranks[page.Limit-1], CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return result, nil
+}
+
+func (r *PhotoRepo) CountText(ctx context.Context, userID int64, text string) (int, error) {
+	query := `
+		SELECT COUNT(*)
 		FROM photos
-		WHERE user_id = $1 AND tags @> ARRAY[$2]::text[]
-		ORDER BY created_at DESC
+		WHERE user_id = $1
+			AND search_vector @@ (websearch_to_tsquery('russian', $2) || websearch_to_tsquery('simple', $2))
 	`
 
-	rows, err := r.pool.Query(ctx, query, userID, tag)
+	var count int
+	if err := r.pool.QueryRow(ctx, query, userID, text).Scan(&count); err != nil {
+		logx.Error("db: failed to count photos by text", "user_id", userID, "text", text, "error", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *PhotoRepo) SuggestTags(ctx context.Context, userID int64, prefix string, limit int) ([]string, error) {
+	query := `
+		SELECT t.name
+		FROM tags t
+		JOIN photo_tags pt ON pt.tag_id = t.id
+		WHERE t.user_id = $1 AND starts_with(t.name, $2)
+		GROUP BY t.id, t.name
+		ORDER BY COUNT(*) DESC, t.name
+		LIMIT $3
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID, prefix, limit)
+	if err != nil {
+		logx.Error("db: failed to suggest tags", "user_id", userID, "prefix", prefix, "error", err)
+		return nil, err
+	}
+
+	tags, err := pgx.CollectRows(rows, pgx.RowTo[string])
 	if err != nil {
-		logx.Error("db: failed to search photos by tag", "user_id", userID, "tag", tag, "error", err)
+		logx.Error("db: failed to read suggested tags", "user_id", userID, "prefix", prefix, "error", err)
 		return nil, err
 	}
+
+	return tags, nil
+}
+
+func (r *PhotoRepo) Delete(ctx context.Context, userID, photoID int64) (bool, error) {
+	query := `
+		DELETE FROM photos
+		WHERE id = $1 AND user_id = $2
+	`
+
+	deleted := false
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		cmd, err := tx.Exec(ctx, query, photoID, userID)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			return nil
+		}
+
+		deleted = true
+		return deleteUnusedTags(ctx, tx, userID)
+	})
+	if err != nil {
+		logx.Error("db: failed to delete photo", "user_id", userID, "photo_id", photoID, "error", err)
+		return false, err
+	}
+
+	return deleted, nil
+}
+
+// photosWithTags selects ids of the user's photos that have any of the tags
+// passed in the given parameter.
+const photosWithTags = `
+	SELECT pt.photo_id
+	FROM photo_tags pt
+	JOIN tags t ON t.id = pt.tag_id
+	WHERE t.user_id = $1 AND t.name = ANY($%d::text[])`
+
+func tagQueryConds(userID int64, q *model.TagQuery) ([]string, []any) {
+	conds := []string{"p.user_id = $1"}
+	args := []any{userID}
+
+	if len(q.All) > 0 {
+		args = append(args, q.All)
+		conds = append(conds, fmt.Sprintf(
+			"p.id IN ("+photosWithTags+" GROUP BY pt.photo_id HAVING COUNT(*) = cardinality($%d::text[]))",
+			len(args), len(args),
+		))
+	}
+
+	for _, group := range q.Any {
+		args = append(args, group)
+		conds = append(conds, fmt.Sprintf("p.id IN ("+photosWithTags+")", len(args)))
+	}
+
+	if len(q.Exclude) > 0 {
+		args = append(args, q.Exclude)
+		conds = append(conds, fmt.Sprintf("p.id NOT IN ("+photosWithTags+")", len(args)))
+	}
+
+	return conds, args
+}
+
+// setPhotoTags links the photo to the given tags, creating the user's tags
+// that do not exist yet.
+func setPhotoTags(ctx context.Context, tx pgx.Tx, userID, photoID int64, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO tags (user_id, name)
+		SELECT DISTINCT $1::bigint, unnest($2::text[])
+		ON CONFLICT (user_id, name) DO NOTHING
+	`, userID, tags)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO photo_tags (photo_id, tag_id)
+		SELECT $3, t.id
+		FROM tags t
+		WHERE t.user_id = $1 AND t.name = ANY($2::text[])
+		ON CONFLICT DO NOTHING
+	`, userID, tags, photoID)
+	return err
+}
+
+// deleteUnusedTags removes the user's tags that are no longer on any photo.
+// Tags that shares point to are kept: deleting them would delete the shares
+// too, while the tag may be put on a photo again.
+func deleteUnusedTags(ctx context.Context, tx pgx.Tx, userID int64) error {
+	_, err := tx.Exec(ctx, `
+		DELETE FROM tags t
+		WHERE t.user_id = $1
+			AND NOT EXISTS (SELECT 1 FROM photo_tags pt WHERE pt.tag_id = t.id)
+			AND NOT EXISTS (SELECT 1 FROM shares s WHERE s.tag_id = t.id)
+	`, userID)
+	return err
+}
+
+func scanPhoto(row pgx.Row, extra ...any) (*model.Photo, error) {
+	photo := &model.Photo{}
+	dest := []any{
+		&photo.ID,
+		&photo.UserID,
+		&photo.TelegramID,
+		&photo.UniqueID,
+		&photo.StorageKey,
+		&photo.Checksum,
+		&photo.FileSize,
+		&photo.Width,
+		&photo.Height,
+		&photo.Description,
+		&photo.Tags,
+		&photo.CreatedAt,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	return photo, nil
+}
+
+func collectPhotos(rows pgx.Rows) ([]*model.Photo, error) {
 	defer rows.Close()
 
 	var photos []*model.Photo
 	for rows.Next() {
-		photo := &model.Photo{}
-		err := rows.Scan(
-			&photo.ID,
-			&photo.UserID,
-			&photo.TelegramID,
-			&photo.FileSize,
-			&photo.Width,
-			&photo.Height,
-			&photo.Description,
-			&photo.Tags,
-			&photo.CreatedAt,
-		)
+		photo, err := scanPhoto(rows)
 		if err != nil {
-			logx.Error("db: failed to scan photo row", "user_id", userID, "tag", tag, "error", err)
 			return nil, err
 		}
 		photos = append(photos, photo)
 	}
 
 	if err := rows.Err(); err != nil {
-		logx.Error("db: error iterating photo rows", "user_id", userID, "tag", tag, "error", err)
 		return nil, err
 	}
 
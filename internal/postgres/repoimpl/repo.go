@@ -1,19 +1,31 @@
 package repoimpl
 
 import (
+	"errors"
 	"picstagsbot/internal/domain/repo"
 	"picstagsbot/pkg/logx"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// uniqueViolation is the SQLSTATE postgres reports for a unique constraint.
+const uniqueViolation = "23505"
+
 func New(pool *pgxpool.Pool) *repo.Repo {
 	r := &repo.Repo{}
 
 	r.UserRepo = NewUserRepo(pool)
 	r.PhotoRepo = NewPhotoRepo(pool)
+	r.CollectionRepo = NewCollectionRepo(pool)
+	r.ShareRepo = NewShareRepo(pool)
 
 	logx.Info("postgres repositories initialized")
 
 	return r
 }
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
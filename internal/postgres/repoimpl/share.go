@@ -0,0 +1,198 @@
+package repoimpl
+
+import (
+	"context"
+	"errors"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/pkg/logx"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// shareColumns selects a share row aliased as s together with the name of the
+// shared tag and the owner's username.
+const shareColumns = `
+	s.id, s.token, s.owner_id, u.username, t.name,
+	COALESCE(s.recipient_id, 0), s.created_at, s.expires_at, s.revoked_at
+	FROM shares s
+	JOIN tags t ON t.id = s.tag_id
+	JOIN users u ON u.id = s.owner_id`
+
+type ShareRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewShareRepo(pool *pgxpool.Pool) *ShareRepo {
+	sr := &ShareRepo{}
+
+	sr.pool = pool
+
+	return sr
+}
+
+// Create stores the share for the owner's tag. It reports false when the
+// owner has no photo with such a tag.
+func (r *ShareRepo) Create(ctx context.Context, share *model.Share) (bool, error) {
+	query := `
+		INSERT INTO shares (token, owner_id, tag_id, created_at, expires_at)
+		SELECT $1, $2, t.id, $4, $5
+		FROM tags t
+		WHERE t.user_id = $2 AND t.name = $3
+			AND EXISTS (SELECT 1 FROM photo_tags pt WHERE pt.tag_id = t.id)
+		RETURNING id
+	`
+
+	err := r.pool.QueryRow(ctx, query, share.Token, share.OwnerID, share.Tag, share.CreatedAt, share.ExpiresAt).Scan(&share.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		logx.Error("db: failed to create share", "owner_id", share.OwnerID, "tag", share.Tag, "error", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *ShareRepo) GetByID(ctx context.Context, shareID int64) (*model.Share, error) {
+	query := `SELECT ` + shareColumns + ` WHERE s.id = $1`
+
+	share, err := scanShare(r.pool.QueryRow(ctx, query, shareID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		logx.Error("db: failed to get share by id", "share_id", shareID, "error", err)
+		return nil, err
+	}
+
+	return share, nil
+}
+
+func (r *ShareRepo) GetByToken(ctx context.Context, token string) (*model.Share, error) {
+	query := `SELECT ` + shareColumns + ` WHERE s.token = $1`
+
+	share, err := scanShare(r.pool.QueryRow(ctx, query, token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		logx.Error("db: failed to get share by token", "error", err)
+		return nil, err
+	}
+
+	return share, nil
+}
+
+// Claim makes the user the recipient of a share nobody has opened yet.
+func (r *ShareRepo) Claim(ctx context.Context, shareID, recipientID int64) (bool, error) {
+	query := `
+		UPDATE shares
+		SET recipient_id = $1
+		WHERE id = $2 AND recipient_id IS NULL
+	`
+
+	cmd, err := r.pool.Exec(ctx, query, recipientID, shareID)
+	if err != nil {
+		logx.Error("db: failed to claim share", "share_id", shareID, "recipient_id", recipientID, "error", err)
+		return false, err
+	}
+
+	return cmd.RowsAffected() > 0, nil
+}
+
+func (r *ShareRepo) Revoke(ctx context.Context, ownerID, shareID int64) (bool, error) {
+	query := `
+		UPDATE shares
+		SET revoked_at = NOW()
+		WHERE id = $1 AND owner_id = $2 AND revoked_at IS NULL
+	`
+
+	cmd, err := r.pool.Exec(ctx, query, shareID, ownerID)
+	if err != nil {
+		logx.Error("db: failed to revoke share", "owner_id", ownerID, "share_id", shareID, "error", err)
+		return false, err
+	}
+
+	return cmd.RowsAffected() > 0, nil
+}
+
+// ListByOwner returns the owner's shares that have not been revoked.
+func (r *ShareRepo) ListByOwner(ctx context.Context, ownerID int64) ([]*model.Share, error) {
+	query := `
+		SELECT ` + shareColumns + `
+		WHERE s.owner_id = $1 AND s.revoked_at IS NULL
+		ORDER BY s.created_at DESC, s.id DESC
+	`
+
+	shares, err := r.listShares(ctx, query, ownerID)
+	if err != nil {
+		logx.Error("db: failed to list shares by owner", "owner_id", ownerID, "error", err)
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+// ListByRecipient returns the shares opened by the recipient that have not
+// been revoked.
+func (r *ShareRepo) ListByRecipient(ctx context.Context, recipientID int64) ([]*model.Share, error) {
+	query := `
+		SELECT ` + shareColumns + `
+		WHERE s.recipient_id = $1 AND s.revoked_at IS NULL
+		ORDER BY s.created_at DESC, s.id DESC
+	`
+
+	shares, err := r.listShares(ctx, query, recipientID)
+	if err != nil {
+		logx.Error("db: failed to list shares by recipient", "recipient_id", recipientID, "error", err)
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+func (r *ShareRepo) listShares(ctx context.Context, query string, userID int64) ([]*model.Share, error) {
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shares []*model.Share
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+func scanShare(row pgx.Row) (*model.Share, error) {
+	share := &model.Share{}
+
+	err := row.Scan(
+		&share.ID,
+		&share.Token,
+		&share.OwnerID,
+		&share.OwnerName,
+		&share.Tag,
+		&share.RecipientID,
+		&share.CreatedAt,
+		&share.ExpiresAt,
+		&share.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return share, nil
+}
@@ -3,6 +3,7 @@ package repoimpl
 import (
 	"context"
 	"errors"
+	"fmt"
 	"picstagsbot/internal/domain/model"
 	"picstagsbot/pkg/logx"
 
@@ -22,23 +23,46 @@ func NewUserRepo(pool *pgxpool.Pool) *UserRepo {
 	return ur
 }
 
-func (r *UserRepo) Create(ctx context.Context, user *model.User) error {
-	query := `INSERT INTO users (telegram_id, username, created_at) VALUES ($1, $2, $3) RETURNING id`
-	err := r.pool.QueryRow(ctx, query, user.TelegramID, user.Username, user.CreatedAt).Scan(&user.ID)
-	if err != nil {
+// Create stores the user unless a user with the same telegram id exists. It
+// reports false and fills user with the stored row in that case.
+func (r *UserRepo) Create(ctx context.Context, user *model.User) (bool, error) {
+	query := `
+		INSERT INTO users (telegram_id, username, kind, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (telegram_id) DO NOTHING
+		RETURNING id
+	`
+
+	err := r.pool.QueryRow(ctx, query, user.TelegramID, user.Username, user.Kind, user.CreatedAt).Scan(&user.ID)
+	if err == nil {
+		return true, nil
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
 		logx.Error("db: failed to create user", "telegram_id", user.TelegramID, "error", err)
-		return err
+		return false, err
 	}
-	return nil
+
+	existing, err := r.GetByTelegramID(ctx, user.TelegramID)
+	if err != nil {
+		return false, err
+	}
+	if existing == nil {
+		logx.Error("db: conflicting user disappeared", "telegram_id", user.TelegramID)
+		return false, fmt.Errorf("user with telegram_id %d not found after conflict", user.TelegramID)
+	}
+
+	*user = *existing
+	return false, nil
 }
 
 func (r *UserRepo) GetByTelegramID(ctx context.Context, telegramID int64) (*model.User, error) {
-	query := `SELECT id, telegram_id, username, created_at FROM users WHERE telegram_id = $1`
+	query := `SELECT id, telegram_id, username, kind, created_at FROM users WHERE telegram_id = $1`
 
 	row := r.pool.QueryRow(ctx, query, telegramID)
 
 	var user model.User
-	err := row.Scan(&user.ID, &user.TelegramID, &user.Username, &user.CreatedAt)
+	err := row.Scan(&user.ID, &user.TelegramID, &user.Username, &user.Kind, &user.CreatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
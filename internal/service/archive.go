@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/internal/domain/repo"
+	"picstagsbot/internal/storage"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"sync"
+	"time"
+)
+
+// FileDownloader fetches the contents and the file_unique_id of a Telegram
+// file.
+type FileDownloader interface {
+	Download(ctx context.Context, fileID string) ([]byte, error)
+	FileUniqueID(ctx context.Context, fileID string) (string, error)
+}
+
+// ArchiveService copies the original bytes of saved photos into blob storage
+// so they can be sent again when their file_id stops working. With a nil
+// store nothing is archived.
+type ArchiveService struct {
+	photoRepo repo.PhotoRepo
+	store     storage.BlobStore
+	files     FileDownloader
+	queue     chan *model.Photo
+}
+
+func NewArchiveService(photoRepo repo.PhotoRepo, store storage.BlobStore, files FileDownloader) *ArchiveService {
+	as := &ArchiveService{}
+
+	as.photoRepo = photoRepo
+	as.store = store
+	as.files = files
+	as.queue = make(chan *model.Photo, constants.ArchiveQueueSize)
+
+	return as
+}
+
+func (svc *ArchiveService) Enabled() bool {
+	return svc.store != nil
+}
+
+// Enqueue schedules a freshly saved photo for archiving. It never blocks; a
+// photo dropped from a full queue is picked up by the next periodic sweep.
+func (svc *ArchiveService) Enqueue(photo *model.Photo) {
+	if !svc.Enabled() {
+		return
+	}
+
+	select {
+	case svc.queue <- photo:
+	default:
+		logx.Warn("archive queue is full", "photo_id", photo.ID)
+	}
+}
+
+// Run archives queued photos as they arrive and, every
+// constants.ArchiveSweepInterval, backfills missing file_unique_ids and
+// sweeps up photos that are still not archived, until ctx is done. Only the
+// backfill runs without a store.
+func (svc *ArchiveService) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	// The queue is drained separately so a long sweep of the whole library
+	// does not hold up freshly saved photos. Archiving a photo twice only
+	// overwrites the same key.
+	if svc.Enabled() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc.drain(ctx)
+		}()
+	}
+
+	ticker := time.NewTicker(constants.ArchiveSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		svc.backfillUniqueIDs(ctx)
+		if svc.Enabled() {
+			svc.sweep(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (svc *ArchiveService) drain(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case photo := <-svc.queue:
+			svc.archive(ctx, photo)
+		}
+	}
+}
+
+// Load returns the stored bytes of the photo after checking them against the
+// recorded checksum.
+func (svc *ArchiveService) Load(ctx context.Context, photo *model.Photo) ([]byte, error) {
+	if !svc.Enabled() || photo.StorageKey == "" {
+		return nil, apperrors.NotFoundError("photo is not archived")
+	}
+
+	data, err := svc.store.Get(ctx, photo.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			logx.Warn("archived photo missing", "photo_id", photo.ID, "storage_key", photo.StorageKey)
+			return nil, apperrors.NotFoundError("archived photo not found")
+		}
+		logx.Error("failed to load archived photo", "photo_id", photo.ID, "error", err)
+		return nil, apperrors.InternalError("failed to load archived photo", err)
+	}
+
+	if sum := storage.Checksum(data); sum != photo.Checksum {
+		logx.Error("archived photo checksum mismatch", "photo_id", photo.ID, "expected", photo.Checksum, "actual", sum)
+		return nil, apperrors.InternalError("archived photo is corrupted", fmt.Errorf("checksum mismatch for photo %d", photo.ID))
+	}
+
+	return data, nil
+}
+
+// ReplaceFileID stores the file_id Telegram assigned to a re-uploaded photo.
+func (svc *ArchiveService) ReplaceFileID(ctx context.Context, photo *model.Photo, fileID string) error {
+	if err := svc.photoRepo.UpdateFileID(ctx, photo.ID, fileID); err != nil {
+		logx.Error("failed to replace photo file_id", "photo_id", photo.ID, "error", err)
+		return apperrors.DatabaseError("failed to update photo", err)
+	}
+
+	logx.Info("photo file_id replaced", "photo_id", photo.ID)
+	photo.TelegramID = fileID
+	return nil
+}
+
+// Remove deletes the stored bytes of a deleted photo.
+func (svc *ArchiveService) Remove(ctx context.Context, photo *model.Photo) {
+	if !svc.Enabled() || photo.StorageKey == "" {
+		return
+	}
+
+	if err := svc.store.Delete(ctx, photo.StorageKey); err != nil {
+		logx.Warn("failed to remove archived photo", "photo_id", photo.ID, "storage_key", photo.StorageKey, "error", err)
+	}
+}
+
+// backfillUniqueIDs looks up the file_unique_id of photos stored before it
+// was recorded, so re-sent old pictures are recognised as duplicates. A
+// legacy row that turns out to duplicate another one is merged into it.
+func (svc *ArchiveService) backfillUniqueIDs(ctx context.Context) {
+	var afterID int64
+	filled, merged := 0, 0
+
+	for ctx.Err() == nil {
+		photos, err := svc.photoRepo.ListWithoutUniqueID(ctx, afterID, constants.ArchiveBatchSize)
+		if err != nil {
+			logx.Error("failed to list photos without unique id", "error", err)
+			return
+		}
+
+		for _, photo := range photos {
+			afterID = photo.ID
+
+			uniqueID, err := svc.files.FileUniqueID(ctx, photo.TelegramID)
+			if err != nil || uniqueID == "" {
+				logx.Warn("failed to get photo unique id", "photo_id", photo.ID, "error", err)
+				svc.fileFailed(ctx, photo)
+				continue
+			}
+
+			dropped, err := svc.photoRepo.SetUniqueID(ctx, photo, uniqueID)
+			if err != nil {
+				continue
+			}
+			if dropped != nil {
+				logx.Info("duplicate photo merged", "photo_id", dropped.ID, "user_id", dropped.UserID)
+				svc.Remove(ctx, dropped)
+				merged++
+			}
+			filled++
+		}
+
+		if len(photos) < constants.ArchiveBatchSize {
+			break
+		}
+	}
+
+	if filled > 0 {
+		logx.Info("photo unique ids backfilled", "filled_count", filled, "merged_count", merged)
+	}
+}
+
+func (svc *ArchiveService) sweep(ctx context.Context) {
+	var afterID int64
+	archived := 0
+
+	for ctx.Err() == nil {
+		photos, err := svc.photoRepo.ListUnarchived(ctx, afterID, constants.ArchiveBatchSize)
+		if err != nil {
+			logx.Error("failed to list unarchived photos", "error", err)
+			return
+		}
+
+		for _, photo := range photos {
+			if svc.archive(ctx, photo) {
+				archived++
+			}
+			afterID = photo.ID
+		}
+
+		if len(photos) < constants.ArchiveBatchSize {
+			break
+		}
+	}
+
+	logx.Info("archive sweep finished", "archived_count", archived)
+}
+
+func (svc *ArchiveService) archive(ctx context.Context, photo *model.Photo) bool {
+	ctx, cancel := context.WithTimeout(ctx, constants.StorageTimeout)
+	defer cancel()
+
+	data, err := svc.files.Download(ctx, photo.TelegramID)
+	if err != nil {
+		logx.Warn("failed to download photo for archive", "photo_id", photo.ID, "error", err)
+		svc.fileFailed(ctx, photo)
+		return false
+	}
+
+	key := storage.PhotoKey(photo.UserID, photo.ID)
+	checksum := storage.Checksum(data)
+
+	if err := svc.store.Put(ctx, key, data); err != nil {
+		logx.Error("failed to store photo", "photo_id", photo.ID, "storage_key", key, "error", err)
+		return false
+	}
+
+	if err := svc.photoRepo.SetStorage(ctx, photo.ID, key, checksum); err != nil {
+		logx.Error("failed to record photo storage", "photo_id", photo.ID, "error", err)
+		return false
+	}
+
+	logx.Info("photo archived", "photo_id", photo.ID, "storage_key", key, "size", len(data))
+	return true
+}
+
+// fileFailed backs off a photo Telegram could not serve, unless the attempt
+// was only cut short by shutdown.
+func (svc *ArchiveService) fileFailed(ctx context.Context, photo *model.Photo) {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+
+	_ = svc.photoRepo.MarkFileFailed(context.WithoutCancel(ctx), photo.ID)
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/internal/domain/repo"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"picstagsbot/pkg/validator"
+	"time"
+)
+
+type CollectionService struct {
+	collectionRepo repo.CollectionRepo
+	userRepo       repo.UserRepo
+}
+
+func NewCollectionService(collectionRepo repo.CollectionRepo, userRepo repo.UserRepo) *CollectionService {
+	cs := &CollectionService{}
+
+	cs.collectionRepo = collectionRepo
+	cs.userRepo = userRepo
+
+	return cs
+}
+
+func (svc *CollectionService) CreateCollection(ctx context.Context, telegramID int64, name string) (*model.Collection, error) {
+	name = validator.SanitizeString(name)
+	if err := validator.ValidateCollectionName(name); err != nil {
+		logx.Warn("invalid collection name", "telegram_id", telegramID, "error", err)
+		return nil, apperrors.ValidationError(err.Error())
+	}
+
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.checkNameFree(ctx, user.ID, 0, name); err != nil {
+		return nil, err
+	}
+
+	collection := &model.Collection{
+		UserID:    user.ID,
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+
+	err = svc.collectionRepo.Create(ctx, collection)
+	if errors.Is(err, repo.ErrDuplicate) {
+		logx.Info("collection name already taken", "user_id", user.ID, "name", name)
+		return nil, apperrors.New(apperrors.ErrAlreadyExists, "collection already exists")
+	}
+	if err != nil {
+		logx.Error("failed to create collection", "telegram_id", telegramID, "user_id", user.ID, "error", err)
+		return nil, apperrors.DatabaseError("failed to create collection", err)
+	}
+
+	logx.Info("collection created", "telegram_id", telegramID, "user_id", user.ID, "collection_id", collection.ID)
+	return collection, nil
+}
+
+func (svc *CollectionService) RenameCollection(ctx context.Context, telegramID, collectionID int64, name string) error {
+	name = validator.SanitizeString(name)
+	if err := validator.ValidateCollectionName(name); err != nil {
+		logx.Warn("invalid collection name", "telegram_id", telegramID, "collection_id", collectionID, "error", err)
+		return apperrors.ValidationError(err.Error())
+	}
+
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.checkNameFree(ctx, user.ID, collectionID, name); err != nil {
+		return err
+	}
+
+	renamed, err := svc.collectionRepo.Rename(ctx, user.ID, collectionID, name)
+	if errors.Is(err, repo.ErrDuplicate) {
+		logx.Info("collection name already taken", "user_id", user.ID, "collection_id", collectionID)
+		return apperrors.New(apperrors.ErrAlreadyExists, "collection already exists")
+	}
+	if err != nil {
+		logx.Error("failed to rename collection", "telegram_id", telegramID, "user_id", user.ID, "collection_id", collectionID, "error", err)
+		return apperrors.DatabaseError("failed to rename collection", err)
+	}
+
+	if !renamed {
+		logx.Warn("collection not found for rename", "telegram_id", telegramID, "user_id", user.ID, "collection_id", collectionID)
+		return apperrors.NotFoundError("collection not found")
+	}
+
+	logx.Info("collection renamed", "telegram_id", telegramID, "user_id", user.ID, "collection_id", collectionID)
+	return nil
+}
+
+func (svc *CollectionService) DeleteCollection(ctx context.Context, telegramID, collectionID int64) error {
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := svc.collectionRepo.Delete(ctx, user.ID, collectionID)
+	if err != nil {
+		logx.Error("failed to delete collection", "telegram_id", telegramID, "user_id", user.ID, "collection_id", collectionID, "error", err)
+		return apperrors.DatabaseError("failed to delete collection", err)
+	}
+
+	if !deleted {
+		logx.Warn("collection not found for delete", "telegram_id", telegramID, "user_id", user.ID, "collection_id", collectionID)
+		return apperrors.NotFoundError("collection not found")
+	}
+
+	logx.Info("collection deleted", "telegram_id", telegramID, "user_id", user.ID, "collection_id", collectionID)
+	return nil
+}
+
+func (svc *CollectionService) ListCollections(ctx context.Context, telegramID int64) ([]*model.Collection, error) {
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	collections, err := svc.collectionRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		logx.Error("failed to list collections", "telegram_id", telegramID, "user_id", user.ID, "error", err)
+		return nil, apperrors.DatabaseError("failed to list collections", err)
+	}
+
+	return collections, nil
+}
+
+func (svc *CollectionService) GetCollection(ctx context.Context, telegramID, collectionID int64) (*model.Collection, error) {
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.getUserCollection(ctx, user, collectionID)
+}
+
+// AddPhotos adds the user's photos to the collection and returns how many of
+// them were not in it yet.
+func (svc *CollectionService) AddPhotos(ctx context.Context, telegramID, collectionID int64, photoIDs []int64) (int, error) {
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := svc.getUserCollection(ctx, user, collectionID); err != nil {
+		return 0, err
+	}
+
+	added, err := svc.collectionRepo.AddPhotos(ctx, collectionID, photoIDs)
+	if err != nil {
+		logx.Error("failed to add photos to collection", "telegram_id", telegramID, "user_id", user.ID, "collection_id", collectionID, "error", err)
+		return 0, apperrors.DatabaseError("failed to add photos to collection", err)
+	}
+
+	logx.Info("photos added to collection", "telegram_id", telegramID, "user_id", user.ID, "collection_id", collectionID, "added_count", added)
+	return added, nil
+}
+
+func (svc *CollectionService) ListCollectionPhotos(ctx context.Context, telegramID, collectionID int64, after *model.Cursor) (*SearchResult, error) {
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, err := svc.getUserCollection(ctx, user, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := svc.collectionRepo.ListPhotos(ctx, collection.ID, model.Page{After: after, Limit: constants.SearchPageSize})
+	if err != nil {
+		logx.Error("failed to list collection photos", "telegram_id", telegramID, "user_id", user.ID, "collection_id", collectionID, "error", err)
+		return nil, apperrors.DatabaseError("failed to list collection photos", err)
+	}
+
+	return &SearchResult{Photos: page.Photos, Next: page.Next, Total: collection.PhotoCount}, nil
+}
+
+func (svc *CollectionService) getUserCollection(ctx context.Context, user *model.User, collectionID int64) (*model.Collection, error) {
+	collection, err := svc.collectionRepo.GetByID(ctx, collectionID)
+	if err != nil {
+		logx.Error("failed to get collection", "user_id", user.ID, "collection_id", collectionID, "error", err)
+		return nil, apperrors.DatabaseError("failed to get collection", err)
+	}
+
+	if collection == nil || collection.UserID != user.ID {
+		logx.Warn("collection not found for user", "user_id", user.ID, "collection_id", collectionID)
+		return nil, apperrors.NotFoundError("collection not found")
+	}
+
+	return collection, nil
+}
+
+// checkNameFree reports ErrAlreadyExists when another collection of the user,
+// other than exceptID, is already called name.
+func (svc *CollectionService) checkNameFree(ctx context.Context, userID, exceptID int64, name string) error {
+	existing, err := svc.collectionRepo.GetByName(ctx, userID, name)
+	if err != nil {
+		logx.Error("failed to check collection name", "user_id", userID, "error", err)
+		return apperrors.DatabaseError("failed to check collection name", err)
+	}
+
+	if existing != nil && existing.ID != exceptID {
+		logx.Info("collection name already taken", "user_id", userID, "collection_id", existing.ID)
+		return apperrors.New(apperrors.ErrAlreadyExists, "collection already exists")
+	}
+
+	return nil
+}
+
+func (svc *CollectionService) getUser(ctx context.Context, telegramID int64) (*model.User, error) {
+	user, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
+	if err != nil {
+		logx.Error("failed to get user", "telegram_id", telegramID, "error", err)
+		return nil, apperrors.DatabaseError("failed to get user", err)
+	}
+
+	if user == nil {
+		logx.Warn("user not found", "telegram_id", telegramID)
+		return nil, apperrors.NotFoundError("user not found")
+	}
+
+	return user, nil
+}
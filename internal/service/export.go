@@ -0,0 +1,301 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/internal/domain/repo"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var exportNameRegex = regexp.MustCompile(`(?:^|/)\d{8}-\d{6}_(\d+)\.jpg$`)
+
+// ExportPart is one finished ZIP file of an export. The file is removed as
+// soon as the callback it is passed to returns.
+type ExportPart struct {
+	Number int
+	Name   string
+	Path   string
+	Photos int
+}
+
+type ExportResult struct {
+	Photos int
+	Failed int
+	Parts  int
+}
+
+type ExportService struct {
+	photoRepo repo.PhotoRepo
+	userRepo  repo.UserRepo
+	files     FileDownloader
+	archive   *ArchiveService
+}
+
+func NewExportService(photoRepo repo.PhotoRepo, userRepo repo.UserRepo, files FileDownloader, archive *ArchiveService) *ExportService {
+	es := &ExportService{}
+
+	es.photoRepo = photoRepo
+	es.userRepo = userRepo
+	es.files = files
+	es.archive = archive
+
+	return es
+}
+
+// manifestEntry describes one exported photo in manifest.json and
+// manifest.csv.
+type manifestEntry struct {
+	File        string   `json:"file"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Width       int      `json:"width"`
+	Height      int      `json:"height"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+// Export packs all the user's photos into ZIP files no larger than
+// constants.ExportPartSize and passes each of them to send. Photos that can
+// be downloaded neither from Telegram nor from storage are skipped and
+// counted as failed.
+func (svc *ExportService) Export(ctx context.Context, telegramID int64, send func(part *ExportPart) error) (*ExportResult, error) {
+	user, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
+	if err != nil {
+		logx.Error("failed to get user for export", "telegram_id", telegramID, "error", err)
+		return nil, apperrors.DatabaseError("failed to get user", err)
+	}
+	if user == nil {
+		logx.Warn("user not found for export", "telegram_id", telegramID)
+		return nil, apperrors.NotFoundError("user not found")
+	}
+
+	result := &ExportResult{}
+	var part *exportWriter
+	defer func() {
+		if part != nil {
+			part.remove()
+		}
+	}()
+
+	finish := func() error {
+		if part == nil {
+			return nil
+		}
+		done := part
+		part = nil
+		defer done.remove()
+
+		if err := done.close(); err != nil {
+			return apperrors.InternalError("failed to write export archive", err)
+		}
+		if err := send(&done.ExportPart); err != nil {
+			return err
+		}
+
+		result.Parts++
+		return nil
+	}
+
+	page := model.Page{Limit: constants.ExportPageSize}
+	for {
+		photos, err := svc.photoRepo.ListByUser(ctx, user.ID, page)
+		if err != nil {
+			logx.Error("failed to list photos for export", "telegram_id", telegramID, "user_id", user.ID, "error", err)
+			return nil, apperrors.DatabaseError("failed to list photos", err)
+		}
+
+		for _, p := range photos.Photos {
+			data, err := svc.download(ctx, p)
+			if ctx.Err() != nil {
+				logx.Warn("export interrupted", "telegram_id", telegramID, "error", ctx.Err())
+				return nil, apperrors.New(apperrors.ErrTimeout, "export interrupted")
+			}
+			if err != nil {
+				logx.Warn("failed to download photo for export", "telegram_id", telegramID, "photo_id", p.ID, "error", err)
+				result.Failed++
+				continue
+			}
+
+			if part != nil && part.size+int64(len(data)) > constants.ExportPartSize {
+				if err := finish(); err != nil {
+					return nil, err
+				}
+			}
+
+			if part == nil {
+				part, err = newExportWriter(result.Parts + 1)
+				if err != nil {
+					logx.Error("failed to create export archive", "telegram_id", telegramID, "error", err)
+					return nil, apperrors.InternalError("failed to create export archive", err)
+				}
+			}
+
+			if err := part.add(p, data); err != nil {
+				logx.Error("failed to add photo to export", "telegram_id", telegramID, "photo_id", p.ID, "error", err)
+				return nil, apperrors.InternalError("failed to write export archive", err)
+			}
+			result.Photos++
+		}
+
+		if photos.Next == nil {
+			break
+		}
+		page.After = photos.Next
+	}
+
+	if err := finish(); err != nil {
+		return nil, err
+	}
+
+	logx.Info("library exported", "telegram_id", telegramID, "user_id", user.ID, "photos_count", result.Photos, "failed_count", result.Failed, "parts", result.Parts)
+	return result, nil
+}
+
+// download fetches the photo through getFile and falls back to the stored
+// copy when Telegram no longer has it.
+func (svc *ExportService) download(ctx context.Context, p *model.Photo) ([]byte, error) {
+	data, err := svc.files.Download(ctx, p.TelegramID)
+	if err == nil {
+		return data, nil
+	}
+
+	if stored, loadErr := svc.archive.Load(ctx, p); loadErr == nil {
+		return stored, nil
+	}
+	return nil, err
+}
+
+// exportWriter writes one part of an export into a temporary file.
+type exportWriter struct {
+	ExportPart
+	file     *os.File
+	zip      *zip.Writer
+	size     int64
+	manifest []manifestEntry
+}
+
+func newExportWriter(number int) (*exportWriter, error) {
+	file, err := os.CreateTemp("", "picstags-export-*.zip")
+	if err != nil {
+		return nil, err
+	}
+
+	w := &exportWriter{}
+	w.Number = number
+	w.Name = fmt.Sprintf("picstags-export-%s-part%d.zip", time.Now().Format("20060102"), number)
+	w.Path = file.Name()
+	w.file = file
+	w.zip = zip.NewWriter(file)
+
+	return w, nil
+}
+
+// add stores the photo without compression: JPEG does not shrink anyway.
+func (w *exportWriter) add(p *model.Photo, data []byte) error {
+	name := exportFileName(p)
+
+	f, err := w.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: p.CreatedAt})
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+
+	w.size += int64(len(data))
+	w.Photos++
+	w.manifest = append(w.manifest, manifestEntry{
+		File:        name,
+		Description: p.Description,
+		Tags:        p.Tags,
+		Width:       p.Width,
+		Height:      p.Height,
+		CreatedAt:   p.CreatedAt.Format(time.RFC3339),
+	})
+	return nil
+}
+
+func (w *exportWriter) close() error {
+	if err := w.writeJSON(); err != nil {
+		return err
+	}
+	if err := w.writeCSV(); err != nil {
+		return err
+	}
+	if err := w.zip.Close(); err != nil {
+		return err
+	}
+	return w.file.Close()
+}
+
+func (w *exportWriter) writeJSON() error {
+	f, err := w.zip.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(w.manifest)
+}
+
+func (w *exportWriter) writeCSV() error {
+	f, err := w.zip.Create("manifest.csv")
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(f)
+	if err := cw.Write([]string{"file", "description", "tags", "width", "height", "created_at"}); err != nil {
+		return err
+	}
+	for _, e := range w.manifest {
+		record := []string{
+			e.File,
+			e.Description,
+			strings.Join(e.Tags, " "),
+			strconv.Itoa(e.Width),
+			strconv.Itoa(e.Height),
+			e.CreatedAt,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportFileName names the photo inside the archive. The name ends with the
+// photo id so an edited manifest can be imported back.
+func exportFileName(p *model.Photo) string {
+	return fmt.Sprintf("photos/%s_%d.jpg", p.CreatedAt.Format("20060102-150405"), p.ID)
+}
+
+// photoIDFromExportName extracts the photo id from a name made by
+// exportFileName. The directory part is optional.
+func photoIDFromExportName(name string) (int64, bool) {
+	m := exportNameRegex.FindStringSubmatch(name)
+	if m == nil {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(m[1], 10, 64)
+	return id, err == nil
+}
+
+func (w *exportWriter) remove() {
+	w.file.Close()
+	os.Remove(w.Path)
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/internal/domain/repo"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"picstagsbot/pkg/validator"
+	"strings"
+)
+
+// ImportPlan is the checked content of an import file. Unknown and Invalid
+// hold the numbers of the rows that were skipped.
+type ImportPlan struct {
+	Edits   []model.PhotoEdit
+	Rows    int
+	Unknown []int
+	Invalid []int
+}
+
+type ImportService struct {
+	photoRepo repo.PhotoRepo
+	userRepo  repo.UserRepo
+	tagMode   validator.TagMode
+}
+
+func NewImportService(photoRepo repo.PhotoRepo, userRepo repo.UserRepo, tagMode validator.TagMode) *ImportService {
+	is := &ImportService{}
+
+	is.photoRepo = photoRepo
+	is.userRepo = userRepo
+	is.tagMode = tagMode
+
+	return is
+}
+
+// importRow is one row of an import file. A photo is referenced by its
+// file_unique_id or by its file name from an export.
+type importRow struct {
+	Line        int
+	UniqueID    string
+	File        string
+	Description string
+	Tags        []string
+}
+
+// Plan parses a CSV or JSON import file and matches its rows against the
+// user's photos without changing anything.
+func (svc *ImportService) Plan(ctx context.Context, telegramID int64, fileName string, data []byte) (*ImportPlan, error) {
+	rows, err := parseImport(fileName, data)
+	if err != nil {
+		logx.Warn("invalid import file", "telegram_id", telegramID, "file_name", fileName, "error", err)
+		return nil, apperrors.ValidationError(err.Error())
+	}
+
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ImportPlan{Rows: len(rows)}
+	planned := make(map[int64]int)
+
+	for _, row := range rows {
+		photo, err := svc.findPhoto(ctx, user.ID, row)
+		if err != nil {
+			logx.Error("failed to match import row", "telegram_id", telegramID, "line", row.Line, "error", err)
+			return nil, apperrors.DatabaseError("failed to match import rows", err)
+		}
+		if photo == nil {
+			plan.Unknown = append(plan.Unknown, row.Line)
+			continue
+		}
+
+		edit, err := importEdit(photo, row, svc.tagMode)
+		if err != nil {
+			plan.Invalid = append(plan.Invalid, row.Line)
+			continue
+		}
+
+		// A later row for the same photo wins.
+		if i, ok := planned[photo.ID]; ok {
+			plan.Edits[i] = edit
+			continue
+		}
+		planned[photo.ID] = len(plan.Edits)
+		plan.Edits = append(plan.Edits, edit)
+	}
+
+	logx.Info("import planned", "telegram_id", telegramID, "user_id", user.ID, "rows", plan.Rows, "matched", len(plan.Edits), "unknown", len(plan.Unknown), "invalid", len(plan.Invalid))
+	return plan, nil
+}
+
+// Apply stores all the edits of the plan in one transaction.
+func (svc *ImportService) Apply(ctx context.Context, telegramID int64, plan *ImportPlan) (int, error) {
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return 0, err
+	}
+
+	updated, err := svc.photoRepo.ApplyEdits(ctx, user.ID, plan.Edits)
+	if err != nil {
+		logx.Error("failed to apply import", "telegram_id", telegramID, "user_id", user.ID, "error", err)
+		return 0, apperrors.DatabaseError("failed to apply import", err)
+	}
+
+	logx.Info("import applied", "telegram_id", telegramID, "user_id", user.ID, "updated", updated)
+	return updated, nil
+}
+
+func (svc *ImportService) findPhoto(ctx context.Context, userID int64, row importRow) (*model.Photo, error) {
+	if row.UniqueID != "" {
+		return svc.photoRepo.GetByUniqueID(ctx, userID, row.UniqueID, "")
+	}
+
+	photoID, ok := photoIDFromExportName(row.File)
+	if !ok {
+		return nil, nil
+	}
+
+	photo, err := svc.photoRepo.GetByID(ctx, photoID)
+	if err != nil || photo == nil || photo.UserID != userID {
+		return nil, err
+	}
+	return photo, nil
+}
+
+func (svc *ImportService) getUser(ctx context.Context, telegramID int64) (*model.User, error) {
+	user, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
+	if err != nil {
+		logx.Error("failed to get user", "telegram_id", telegramID, "error", err)
+		return nil, apperrors.DatabaseError("failed to get user", err)
+	}
+
+	if user == nil {
+		logx.Warn("user not found", "telegram_id", telegramID)
+		return nil, apperrors.NotFoundError("user not found")
+	}
+
+	return user, nil
+}
+
+// importEdit validates a row. Tags come from the tags column, in which case a
+// row without a description keeps the photo's current one. Every entry of the
+// column must be a valid tag: unlike a description, nothing is skipped, so a
+// mistyped tag makes the row invalid. Without tags the description is parsed
+// the same way as on upload, according to mode.
+func importEdit(photo *model.Photo, row importRow, mode validator.TagMode) (model.PhotoEdit, error) {
+	if len(row.Tags) == 0 {
+		description, tags, err := validator.ParseDescription(row.Description, mode)
+		if err != nil {
+			return model.PhotoEdit{}, err
+		}
+		return model.PhotoEdit{PhotoID: photo.ID, Description: description, Tags: tags}, nil
+	}
+
+	tags := validator.NormalizeTags(row.Tags)
+	if err := validator.ValidateTags(tags); err != nil {
+		return model.PhotoEdit{}, err
+	}
+
+	description := validator.SanitizeString(row.Description)
+	if description == "" {
+		description = photo.Description
+	} else if err := validator.ValidateDescription(description); err != nil {
+		return model.PhotoEdit{}, err
+	}
+
+	return model.PhotoEdit{PhotoID: photo.ID, Description: description, Tags: tags}, nil
+}
+
+func parseImport(fileName string, data []byte) ([]importRow, error) {
+	var rows []importRow
+	var err error
+
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".json":
+		rows, err = parseImportJSON(data)
+	case ".csv":
+		rows, err = parseImportCSV(data)
+	default:
+		return nil, fmt.Errorf("unsupported import file type: %q", fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("import file has no rows")
+	}
+	if len(rows) > constants.MaxImportRows {
+		return nil, fmt.Errorf("too many rows: maximum %d", constants.MaxImportRows)
+	}
+
+	return rows, nil
+}
+
+// parseImportJSON reads an array of objects in the format of manifest.json,
+// optionally with a file_unique_id field.
+func parseImportJSON(data []byte) ([]importRow, error) {
+	var entries []struct {
+		File        string   `json:"file"`
+		UniqueID    string   `json:"file_unique_id"`
+		Description string   `json:"description"`
+		Tags        []string `json:"tags"`
+	}
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+
+	rows := make([]importRow, 0, len(entries))
+	for i, e := range entries {
+		rows = append(rows, importRow{
+			Line:        i + 1,
+			UniqueID:    strings.TrimSpace(e.UniqueID),
+			File:        strings.TrimSpace(e.File),
+			Description: e.Description,
+			Tags:        e.Tags,
+		})
+	}
+
+	return rows, nil
+}
+
+// parseImportCSV reads a table with a header row in the format of
+// manifest.csv: a file or file_unique_id column plus optional description
+// and space separated tags columns.
+func parseImportCSV(data []byte) ([]importRow, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	_, hasFile := columns["file"]
+	_, hasUniqueID := columns["file_unique_id"]
+	if !hasFile && !hasUniqueID {
+		return nil, fmt.Errorf("csv needs a file or file_unique_id column")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []importRow
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+
+		line, _ := r.FieldPos(0)
+		rows = append(rows, importRow{
+			Line:        line,
+			UniqueID:    field(record, "file_unique_id"),
+			File:        field(record, "file"),
+			Description: field(record, "description"),
+			Tags:        strings.Fields(field(record, "tags")),
+		})
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/pkg/constants"
+	"picstagsbot/pkg/validator"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	data := "\xef\xbb\xbfFile,Description,tags,width\n" +
+		"photos/20240102-030405_42.jpg,\"Кот, на диване\",кот диван,800\n" +
+		"\"photos/20240102-030406_43.jpg\",\"две\nстроки\",,600\n" +
+		"short\n"
+
+	rows, err := parseImportCSV([]byte(data))
+	if err != nil {
+		t.Fatalf("parseImportCSV: %v", err)
+	}
+
+	want := []importRow{
+		{Line: 2, File: "photos/20240102-030405_42.jpg", Description: "Кот, на диване", Tags: []string{"кот", "диван"}},
+		{Line: 3, File: "photos/20240102-030406_43.jpg", Description: "две\nстроки", Tags: []string{}},
+		{Line: 5, File: "short", Tags: []string{}},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %#v\nwant %#v", rows, want)
+	}
+}
+
+func TestParseImportCSVUniqueID(t *testing.T) {
+	rows, err := parseImportCSV([]byte("file_unique_id,tags\nAQADabc , море\n"))
+	if err != nil {
+		t.Fatalf("parseImportCSV: %v", err)
+	}
+	if len(rows) != 1 || rows[0].UniqueID != "AQADabc" || !slices.Equal(rows[0].Tags, []string{"море"}) {
+		t.Errorf("rows = %#v", rows)
+	}
+}
+
+func TestParseImportCSVErrors(t *testing.T) {
+	tests := map[string]string{
+		"empty":          "",
+		"no id column":   "description,tags\nкот,кот\n",
+		"broken quoting": "file,tags\n\"unterminated,кот\n",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseImportCSV([]byte(data)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseImportJSON(t *testing.T) {
+	data := `[
+		{"file": " photos/20240102-030405_42.jpg ", "description": "кот", "tags": ["кот"], "width": 800},
+		{"file_unique_id": "AQADabc", "tags": []}
+	]`
+
+	rows, err := parseImportJSON([]byte(data))
+	if err != nil {
+		t.Fatalf("parseImportJSON: %v", err)
+	}
+
+	want := []importRow{
+		{Line: 1, File: "photos/20240102-030405_42.jpg", Description: "кот", Tags: []string{"кот"}},
+		{Line: 2, UniqueID: "AQADabc", Tags: []string{}},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %#v\nwant %#v", rows, want)
+	}
+
+	for _, bad := range []string{`{"file": "x"}`, `[{"tags": "кот"}]`, `not json`} {
+		if _, err := parseImportJSON([]byte(bad)); err == nil {
+			t.Errorf("parseImportJSON(%s) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestParseImport(t *testing.T) {
+	if _, err := parseImport("tags.txt", []byte("file\nx\n")); err == nil {
+		t.Error("expected an error for an unsupported extension")
+	}
+	if _, err := parseImport("tags.CSV", []byte("file\n")); err == nil {
+		t.Error("expected an error for a file without rows")
+	}
+	if rows, err := parseImport("manifest.JSON", []byte(`[{"file": "x"}]`)); err != nil || len(rows) != 1 {
+		t.Errorf("parseImport = %v, %v", rows, err)
+	}
+}
+
+func TestPhotoIDFromExportName(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     int64
+		wantOK bool
+	}{
+		{"photos/20240102-030405_42.jpg", 42, true},
+		{"20240102-030405_7.jpg", 7, true},
+		{"backup/photos/20240102-030405_9001.jpg", 9001, true},
+		{"photos/20240102-030405_42.png", 0, false},
+		{"photos/42.jpg", 0, false},
+		{"photos/2024-01-02_42.jpg", 0, false},
+		{"photos/20240102-030405_99999999999999999999.jpg", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		id, ok := photoIDFromExportName(tt.name)
+		if ok != tt.wantOK || (ok && id != tt.id) {
+			t.Errorf("photoIDFromExportName(%q) = %d, %v, want %d, %v", tt.name, id, ok, tt.id, tt.wantOK)
+		}
+	}
+}
+
+func TestExportFileNameRoundTrip(t *testing.T) {
+	p := &model.Photo{ID: 123, CreatedAt: time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)}
+
+	name := exportFileName(p)
+	if id, ok := photoIDFromExportName(name); !ok || id != p.ID {
+		t.Errorf("photoIDFromExportName(%q) = %d, %v", name, id, ok)
+	}
+}
+
+func TestImportEdit(t *testing.T) {
+	photo := &model.Photo{ID: 5, Description: "старое"}
+
+	tests := []struct {
+		name    string
+		row     importRow
+		mode    validator.TagMode
+		want    model.PhotoEdit
+		wantErr bool
+	}{
+		{
+			name: "tags column keeps current description",
+			row:  importRow{Tags: []string{"Кот", "#диван"}},
+			mode: validator.TagModeWords,
+			want: model.PhotoEdit{PhotoID: 5, Description: "старое", Tags: []string{"кот", "диван"}},
+		},
+		{
+			name: "description in words mode",
+			row:  importRow{Description: "кот на диване"},
+			mode: validator.TagModeWords,
+			want: model.PhotoEdit{PhotoID: 5, Description: "кот на диване", Tags: []string{"кот", "на", "диване"}},
+		},
+		{
+			name: "description in hashtags mode",
+			row:  importRow{Description: "кот на #диване"},
+			mode: validator.TagModeHashtags,
+			want: model.PhotoEdit{PhotoID: 5, Description: "кот на", Tags: []string{"диване"}},
+		},
+		{
+			name:    "invalid characters in tags column",
+			row:     importRow{Tags: []string{"кот!", "диван"}},
+			mode:    validator.TagModeWords,
+			wantErr: true,
+		},
+		{
+			name:    "foreign tag in tags column",
+			row:     importRow{Tags: []string{"café"}},
+			mode:    validator.TagModeWords,
+			wantErr: true,
+		},
+		{
+			name:    "empty row",
+			row:     importRow{},
+			mode:    validator.TagModeWords,
+			wantErr: true,
+		},
+		{
+			name:    "description too long",
+			row:     importRow{Description: strings.Repeat("я", constants.MaxDescriptionLen+1), Tags: []string{"кот"}},
+			mode:    validator.TagModeWords,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := importEdit(photo, tt.row, tt.mode)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("importEdit = %#v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("importEdit: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("importEdit = %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/internal/domain/repo"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"picstagsbot/pkg/validator"
+)
+
+type PhotoService struct {
+	photoRepo repo.PhotoRepo
+	userRepo  repo.UserRepo
+	archive   *ArchiveService
+	tagMode   validator.TagMode
+}
+
+func NewPhotoService(photoRepo repo.PhotoRepo, userRepo repo.UserRepo, archive *ArchiveService, tagMode validator.TagMode) *PhotoService {
+	ps := &PhotoService{}
+
+	ps.photoRepo = photoRepo
+	ps.userRepo = userRepo
+	ps.archive = archive
+	ps.tagMode = tagMode
+
+	return ps
+}
+
+func (svc *PhotoService) GetUserPhoto(ctx context.Context, telegramID int64, photoID int64) (*model.Photo, error) {
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	photo, err := svc.photoRepo.GetByID(ctx, photoID)
+	if err != nil {
+		logx.Error("failed to get photo", "telegram_id", telegramID, "photo_id", photoID, "error", err)
+		return nil, apperrors.DatabaseError("failed to get photo", err)
+	}
+
+	if photo == nil || photo.UserID != user.ID {
+		logx.Warn("photo not found for user", "telegram_id", telegramID, "user_id", user.ID, "photo_id", photoID)
+		return nil, apperrors.NotFoundError("photo not found")
+	}
+
+	return photo, nil
+}
+
+func (svc *PhotoService) DeletePhoto(ctx context.Context, telegramID int64, photoID int64) error {
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+
+	photo, err := svc.photoRepo.GetByID(ctx, photoID)
+	if err != nil {
+		logx.Error("failed to get photo for delete", "telegram_id", telegramID, "photo_id", photoID, "error", err)
+		return apperrors.DatabaseError("failed to get photo", err)
+	}
+
+	deleted, err := svc.photoRepo.Delete(ctx, user.ID, photoID)
+	if err != nil {
+		logx.Error("failed to delete photo", "telegram_id", telegramID, "user_id", user.ID, "photo_id", photoID, "error", err)
+		return apperrors.DatabaseError("failed to delete photo", err)
+	}
+
+	if !deleted {
+		logx.Warn("photo not found for delete", "telegram_id", telegramID, "user_id", user.ID, "photo_id", photoID)
+		return apperrors.NotFoundError("photo not found")
+	}
+
+	if photo != nil {
+		svc.archive.Remove(ctx, photo)
+	}
+
+	logx.Info("photo deleted", "telegram_id", telegramID, "user_id", user.ID, "photo_id", photoID)
+	return nil
+}
+
+func (svc *PhotoService) EditDescription(ctx context.Context, telegramID int64, photoID int64, description string) (*model.Photo, error) {
+	description, tags, err := validator.ParseDescription(description, svc.tagMode)
+	if err != nil {
+		logx.Warn("invalid description or tags", "telegram_id", telegramID, "photo_id", photoID, "error", err)
+		return nil, apperrors.ValidationError(err.Error())
+	}
+
+	photo, err := svc.GetUserPhoto(ctx, telegramID, photoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.photoRepo.UpdateDescription(ctx, photo.ID, description, tags); err != nil {
+		logx.Error("failed to update photo description", "telegram_id", telegramID, "photo_id", photoID, "tags_count", len(tags), "error", err)
+		return nil, apperrors.DatabaseError("failed to update photo description", err)
+	}
+
+	photo.Description = description
+	photo.Tags = tags
+
+	logx.Info("photo description edited", "telegram_id", telegramID, "user_id", photo.UserID, "photo_id", photoID, "tags_count", len(tags))
+	return photo, nil
+}
+
+func (svc *PhotoService) getUser(ctx context.Context, telegramID int64) (*model.User, error) {
+	user, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
+	if err != nil {
+		logx.Error("failed to get user", "telegram_id", telegramID, "error", err)
+		return nil, apperrors.DatabaseError("failed to get user", err)
+	}
+
+	if user == nil {
+		logx.Warn("user not found", "telegram_id", telegramID)
+		return nil, apperrors.NotFoundError("user not found")
+	}
+
+	return user, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"picstagsbot/internal/domain/model"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/validator"
+	"strings"
+)
+
+const (
+	queryOr  = "|"
+	queryNot = "-"
+)
+
+// Reasons a search query token is rejected, besides the validator.ErrTag*
+// errors of an invalid tag.
+var (
+	ErrQueryEmpty         = errors.New("query cannot be empty")
+	ErrQueryOperator      = errors.New("operator must be placed between two tags")
+	ErrQueryExcludedInAny = errors.New("excluded tag cannot be part of an OR group")
+)
+
+type TokenError struct {
+	Token string
+	Err   error
+}
+
+// QueryError lists every token of a search query that could not be parsed.
+// It unwraps to apperrors.ErrValidation.
+type QueryError struct {
+	Tokens []TokenError
+}
+
+func (e *QueryError) Error() string {
+	parts := make([]string, 0, len(e.Tokens))
+	for _, t := range e.Tokens {
+		parts = append(parts, fmt.Sprintf("%q: %v", t.Token, t.Err))
+	}
+	return "invalid search query: " + strings.Join(parts, "; ")
+}
+
+func (e *QueryError) Unwrap() error {
+	return apperrors.ErrValidation
+}
+
+// ParseTagQuery parses queries like "море закат -люди" or "кот | собака".
+// Space separated tags are combined with AND, tags joined by "|" form an OR
+// group and a leading "-" excludes the tag. Tags are normalized the same way
+// as when they are saved.
+func ParseTagQuery(raw string) (*model.TagQuery, error) {
+	raw = validator.SanitizeString(raw)
+	tokens := strings.Fields(strings.ReplaceAll(raw, queryOr, " "+queryOr+" "))
+
+	q := &model.TagQuery{}
+	qerr := &QueryError{}
+
+	if len(tokens) == 0 {
+		qerr.Tokens = append(qerr.Tokens, TokenError{Token: raw, Err: ErrQueryEmpty})
+		return nil, qerr
+	}
+
+	var group []string
+	flush := func() {
+		switch len(group) {
+		case 0:
+		case 1:
+			q.All = appendUnique(q.All, group[0])
+		default:
+			q.Any = append(q.Any, group)
+		}
+		group = nil
+	}
+
+	for i, token := range tokens {
+		if token == queryOr {
+			if len(group) == 0 || i == len(tokens)-1 || tokens[i+1] == queryOr {
+				qerr.Tokens = append(qerr.Tokens, TokenError{Token: token, Err: ErrQueryOperator})
+			}
+			continue
+		}
+
+		joined := i > 0 && tokens[i-1] == queryOr && len(group) > 0
+
+		if strings.HasPrefix(token, queryNot) {
+			tag := validator.NormalizeTag(strings.TrimPrefix(token, queryNot))
+			if joined || (i+1 < len(tokens) && tokens[i+1] == queryOr) {
+				qerr.Tokens = append(qerr.Tokens, TokenError{Token: token, Err: ErrQueryExcludedInAny})
+				continue
+			}
+			if err := validator.ValidateTag(tag); err != nil {
+				qerr.Tokens = append(qerr.Tokens, TokenError{Token: token, Err: err})
+				continue
+			}
+			flush()
+			q.Exclude = appendUnique(q.Exclude, tag)
+			continue
+		}
+
+		tag := validator.NormalizeTag(token)
+		if err := validator.ValidateTag(tag); err != nil {
+			qerr.Tokens = append(qerr.Tokens, TokenError{Token: token, Err: err})
+			continue
+		}
+
+		if !joined {
+			flush()
+		}
+		group = appendUnique(group, tag)
+	}
+	flush()
+
+	if len(qerr.Tokens) > 0 {
+		return nil, qerr
+	}
+
+	return q, nil
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
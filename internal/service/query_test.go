@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/pkg/validator"
+	"reflect"
+	"testing"
+)
+
+func TestParseTagQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  *model.TagQuery
+	}{
+		{"море", &model.TagQuery{All: []string{"море"}}},
+		{"Море  закат море", &model.TagQuery{All: []string{"море", "закат"}}},
+		{"#море #Закат", &model.TagQuery{All: []string{"море", "закат"}}},
+		{"кот | собака", &model.TagQuery{Any: [][]string{{"кот", "собака"}}}},
+		{"кот|собака|кот", &model.TagQuery{Any: [][]string{{"кот", "собака"}}}},
+		{"море кот | собака закат", &model.TagQuery{All: []string{"море", "закат"}, Any: [][]string{{"кот", "собака"}}}},
+		{"море -люди", &model.TagQuery{All: []string{"море"}, Exclude: []string{"люди"}}},
+		{"-люди -Люди", &model.TagQuery{Exclude: []string{"люди"}}},
+		{"кот | собака -люди море", &model.TagQuery{All: []string{"море"}, Any: [][]string{{"кот", "собака"}}, Exclude: []string{"люди"}}},
+		{"кот|собака птица|рыба", &model.TagQuery{Any: [][]string{{"кот", "собака"}, {"птица", "рыба"}}}},
+		{"кот | кот", &model.TagQuery{All: []string{"кот"}}},
+		{"new-year", &model.TagQuery{All: []string{"new-year"}}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTagQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseTagQuery(%q): %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTagQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagQueryErrors(t *testing.T) {
+	type tokenErr struct {
+		token string
+		err   error
+	}
+
+	tests := []struct {
+		query string
+		want  []tokenErr
+	}{
+		{"   ", []tokenErr{{"", ErrQueryEmpty}}},
+		{"|", []tokenErr{{"|", ErrQueryOperator}}},
+		{"| кот", []tokenErr{{"|", ErrQueryOperator}}},
+		{"кот |", []tokenErr{{"|", ErrQueryOperator}}},
+		{"кот || собака", []tokenErr{{"|", ErrQueryOperator}}},
+		{"кот | -собака", []tokenErr{{"-собака", ErrQueryExcludedInAny}}},
+		{"-кот | собака", []tokenErr{{"-кот", ErrQueryExcludedInAny}, {"|", ErrQueryOperator}}},
+		{"-", []tokenErr{{"-", validator.ErrTagEmpty}}},
+		{"кот! со.бака", []tokenErr{{"кот!", validator.ErrTagInvalidChars}, {"со.бака", validator.ErrTagInvalidChars}}},
+		{"-кот!", []tokenErr{{"-кот!", validator.ErrTagInvalidChars}}},
+	}
+
+	for _, tt := range tests {
+		q, err := ParseTagQuery(tt.query)
+
+		var qerr *QueryError
+		if !errors.As(err, &qerr) {
+			t.Errorf("ParseTagQuery(%q) = %+v, %v, want a QueryError", tt.query, q, err)
+			continue
+		}
+
+		got := make([]tokenErr, 0, len(qerr.Tokens))
+		for _, te := range qerr.Tokens {
+			got = append(got, tokenErr{te.Token, te.Err})
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseTagQuery(%q) tokens = %v, want %v", tt.query, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].token != tt.want[i].token || !errors.Is(got[i].err, tt.want[i].err) {
+				t.Errorf("ParseTagQuery(%q) token %d = %q: %v, want %q: %v", tt.query, i, got[i].token, got[i].err, tt.want[i].token, tt.want[i].err)
+			}
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	apperrors "picstagsbot/pkg/errors"
 	"picstagsbot/pkg/logx"
 	"picstagsbot/pkg/validator"
+	"strconv"
 	"time"
 )
 
@@ -29,28 +30,51 @@ func (svc *RegService) RegisterUser(ctx context.Context, telegramID int64, usern
 		return false, apperrors.ValidationError(err.Error())
 	}
 
-	existingUser, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
-	if err != nil {
-		logx.Error("failed to get user", "telegram_id", telegramID, "error", err)
-		return false, apperrors.DatabaseError("failed to get user", err)
-	}
-
-	if existingUser != nil {
-		logx.Info("user already registered", "telegram_id", telegramID, "username", username)
-		return true, nil
-	}
-
 	botUser := &model.User{
 		TelegramID: telegramID,
 		Username:   username,
+		Kind:       model.UserKindPrivate,
 		CreatedAt:  time.Now(),
 	}
 
-	if err := svc.userRepo.Create(ctx, botUser); err != nil {
+	created, err := svc.userRepo.Create(ctx, botUser)
+	if err != nil {
 		logx.Error("failed to create user", "telegram_id", telegramID, "username", username, "error", err)
 		return false, apperrors.DatabaseError("failed to create user", err)
 	}
 
+	if !created {
+		logx.Info("user already registered", "telegram_id", telegramID, "username", username)
+		return true, nil
+	}
+
 	logx.Info("user registered", "telegram_id", telegramID, "username", username, "user_id", botUser.ID)
 	return false, nil
 }
+
+// RegisterChat returns the library owner of a group chat, creating it on the
+// first use of the bot in the chat.
+func (svc *RegService) RegisterChat(ctx context.Context, chatID int64, title string) (*model.User, error) {
+	title = validator.SanitizeString(title)
+	if err := validator.ValidateUsername(title); err != nil {
+		title = strconv.FormatInt(chatID, 10)
+	}
+
+	chat := &model.User{
+		TelegramID: chatID,
+		Username:   title,
+		Kind:       model.UserKindGroup,
+		CreatedAt:  time.Now(),
+	}
+
+	created, err := svc.userRepo.Create(ctx, chat)
+	if err != nil {
+		logx.Error("failed to create chat", "chat_id", chatID, "error", err)
+		return nil, apperrors.DatabaseError("failed to create chat", err)
+	}
+
+	if created {
+		logx.Info("chat registered", "chat_id", chatID, "user_id", chat.ID)
+	}
+	return chat, nil
+}
@@ -4,9 +4,14 @@ import (
 	"context"
 	"picstagsbot/internal/domain/model"
 	"picstagsbot/internal/domain/repo"
+	"picstagsbot/pkg/constants"
 	apperrors "picstagsbot/pkg/errors"
 	"picstagsbot/pkg/logx"
+	"picstagsbot/pkg/metrics"
 	"picstagsbot/pkg/validator"
+	"slices"
+	"strings"
+	"unicode"
 )
 
 type SearchService struct {
@@ -23,8 +28,16 @@ func NewSearchService(photoRepo repo.PhotoRepo, userRepo repo.UserRepo) *SearchS
 	return sh
 }
 
-func (svc *SearchService) SearchPhotosByTag(ctx context.Context, telegramID int64, tag string) ([]*model.Photo, error) {
-	tag = validator.SanitizeString(tag)
+// SearchResult is a single page of search results. Total is only counted for
+// the first page, when after is nil.
+type SearchResult struct {
+	Photos []*model.Photo
+	Next   *model.Cursor
+	Total  int
+}
+
+func (svc *SearchService) SearchPhotosByTag(ctx context.Context, telegramID int64, tag string, after *model.Cursor) (*SearchResult, error) {
+	tag = validator.NormalizeTag(validator.SanitizeString(tag))
 	if err := validator.ValidateTag(tag); err != nil {
 		logx.Warn("invalid search tag", "telegram_id", telegramID, "tag", tag, "error", err)
 		return nil, apperrors.ValidationError(err.Error())
@@ -41,12 +54,210 @@ func (svc *SearchService) SearchPhotosByTag(ctx context.Context, telegramID int6
 		return nil, apperrors.NotFoundError("user not found")
 	}
 
-	photos, err := svc.photoRepo.SearchByTag(ctx, user.ID, tag)
+	page, err := svc.photoRepo.SearchByTag(ctx, user.ID, tag, model.Page{After: after, Limit: constants.SearchPageSize})
 	if err != nil {
 		logx.Error("failed to search photos by tag", "telegram_id", telegramID, "user_id", user.ID, "tag", tag, "error", err)
 		return nil, apperrors.DatabaseError("failed to search photos", err)
 	}
 
-	logx.Info("photos searched by tag", "telegram_id", telegramID, "user_id", user.ID, "tag", tag, "results_count", len(photos))
-	return photos, nil
+	result := &SearchResult{Photos: page.Photos, Next: page.Next}
+
+	if after == nil {
+		result.Total, err = svc.photoRepo.CountByQuery(ctx, user.ID, &model.TagQuery{All: []string{tag}})
+		if err != nil {
+			logx.Error("failed to count photos by tag", "telegram_id", telegramID, "user_id", user.ID, "tag", tag, "error", err)
+			return nil, apperrors.DatabaseError("failed to count photos", err)
+		}
+	}
+
+	countSearch("tag", after, len(result.Photos))
+	logx.Info("photos searched by tag", "telegram_id", telegramID, "user_id", user.ID, "tag", tag, "results_count", len(result.Photos), "total", result.Total)
+	return result, nil
+}
+
+func (svc *SearchService) ListTags(ctx context.Context, telegramID int64) ([]*model.TagStat, error) {
+	user, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
+	if err != nil {
+		logx.Error("failed to get user for tags", "telegram_id", telegramID, "error", err)
+		return nil, apperrors.DatabaseError("failed to get user", err)
+	}
+
+	if user == nil {
+		logx.Warn("user not found for tags", "telegram_id", telegramID)
+		return nil, apperrors.NotFoundError("user not found")
+	}
+
+	stats, err := svc.photoRepo.TagStats(ctx, user.ID, constants.TagCloudSize)
+	if err != nil {
+		logx.Error("failed to get tag stats", "telegram_id", telegramID, "user_id", user.ID, "error", err)
+		return nil, apperrors.DatabaseError("failed to get tags", err)
+	}
+
+	logx.Info("tags listed", "telegram_id", telegramID, "user_id", user.ID, "tags_count", len(stats))
+	return stats, nil
+}
+
+func (svc *SearchService) SearchPhotosByQuery(ctx context.Context, telegramID int64, query string, after *model.Cursor) (*SearchResult, error) {
+	q, err := ParseTagQuery(query)
+	if err != nil {
+		logx.Warn("invalid search query", "telegram_id", telegramID, "query", query, "error", err)
+		return nil, err
+	}
+
+	user, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
+	if err != nil {
+		logx.Error("failed to get user for search", "telegram_id", telegramID, "query", query, "error", err)
+		return nil, apperrors.DatabaseError("failed to get user", err)
+	}
+
+	if user == nil {
+		logx.Warn("user not found for search", "telegram_id", telegramID, "query", query)
+		return nil, apperrors.NotFoundError("user not found")
+	}
+
+	page, err := svc.photoRepo.SearchByQuery(ctx, user.ID, q, model.Page{After: after, Limit: constants.SearchPageSize})
+	if err != nil {
+		logx.Error("failed to search photos by query", "telegram_id", telegramID, "user_id", user.ID, "query", query, "error", err)
+		return nil, apperrors.DatabaseError("failed to search photos", err)
+	}
+
+	result := &SearchResult{Photos: page.Photos, Next: page.Next}
+
+	if after == nil {
+		result.Total, err = svc.photoRepo.CountByQuery(ctx, user.ID, q)
+		if err != nil {
+			logx.Error("failed to count photos by query", "telegram_id", telegramID, "user_id", user.ID, "query", query, "error", err)
+			return nil, apperrors.DatabaseError("failed to count photos", err)
+		}
+	}
+
+	countSearch("query", after, len(result.Photos))
+	logx.Info("photos searched by query", "telegram_id", telegramID, "user_id", user.ID, "query", query, "results_count", len(result.Photos), "total", result.Total)
+	return result, nil
+}
+
+func (svc *SearchService) SearchPhotosByText(ctx context.Context, telegramID int64, text string, after *model.Cursor) (*SearchResult, error) {
+	text = validator.SanitizeString(text)
+	if err := validator.ValidateDescription(text); err != nil {
+		logx.Warn("invalid search text", "telegram_id", telegramID, "text", text, "error", err)
+		return nil, apperrors.ValidationError(err.Error())
+	}
+
+	user, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
+	if err != nil {
+		logx.Error("failed to get user for search", "telegram_id", telegramID, "text", text, "error", err)
+		return nil, apperrors.DatabaseError("failed to get user", err)
+	}
+
+	if user == nil {
+		logx.Warn("user not found for search", "telegram_id", telegramID, "text", text)
+		return nil, apperrors.NotFoundError("user not found")
+	}
+
+	page, err := svc.photoRepo.SearchText(ctx, user.ID, text, model.Page{After: after, Limit: constants.SearchPageSize})
+	if err != nil {
+		logx.Error("failed to search photos by text", "telegram_id", telegramID, "user_id", user.ID, "text", text, "error", err)
+		return nil, apperrors.DatabaseError("failed to search photos", err)
+	}
+
+	result := &SearchResult{Photos: page.Photos, Next: page.Next}
+
+	if after == nil {
+		result.Total, err = svc.photoRepo.CountText(ctx, user.ID, text)
+		if err != nil {
+			logx.Error("failed to count photos by text", "telegram_id", telegramID, "user_id", user.ID, "text", text, "error", err)
+			return nil, apperrors.DatabaseError("failed to count photos", err)
+		}
+	}
+
+	countSearch("text", after, len(result.Photos))
+	logx.Info("photos searched by text", "telegram_id", telegramID, "user_id", user.ID, "text", text, "results_count", len(result.Photos), "total", result.Total)
+	return result, nil
+}
+
+// SearchPhotosInline serves inline mode queries. An empty query lists the
+// latest photos, and an unfinished last tag is completed from the user's own
+// tags before searching.
+func (svc *SearchService) SearchPhotosInline(ctx context.Context, telegramID int64, query string, after *model.Cursor) (*SearchResult, error) {
+	user, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
+	if err != nil {
+		logx.Error("failed to get user for inline search", "telegram_id", telegramID, "query", query, "error", err)
+		return nil, apperrors.DatabaseError("failed to get user", err)
+	}
+
+	if user == nil {
+		logx.Warn("user not found for inline search", "telegram_id", telegramID, "query", query)
+		return nil, apperrors.NotFoundError("user not found")
+	}
+
+	q := &model.TagQuery{}
+	if strings.TrimSpace(query) != "" {
+		query, err = svc.completeQuery(ctx, user.ID, query)
+		if err != nil {
+			logx.Error("failed to complete inline query", "telegram_id", telegramID, "user_id", user.ID, "query", query, "error", err)
+			return nil, apperrors.DatabaseError("failed to suggest tags", err)
+		}
+
+		q, err = ParseTagQuery(query)
+		if err != nil {
+			logx.Warn("invalid inline query", "telegram_id", telegramID, "query", query, "error", err)
+			return nil, err
+		}
+	}
+
+	page, err := svc.photoRepo.SearchByQuery(ctx, user.ID, q, model.Page{After: after, Limit: constants.InlinePageSize})
+	if err != nil {
+		logx.Error("failed to search photos inline", "telegram_id", telegramID, "user_id", user.ID, "query", query, "error", err)
+		return nil, apperrors.DatabaseError("failed to search photos", err)
+	}
+
+	countSearch("inline", after, len(page.Photos))
+	logx.Info("photos searched inline", "telegram_id", telegramID, "user_id", user.ID, "query", query, "results_count", len(page.Photos))
+	return &SearchResult{Photos: page.Photos, Next: page.Next}, nil
+}
+
+// completeQuery replaces the last, unfinished tag of the query with an OR
+// group of the user's tags starting with it. The query is returned unchanged
+// when it ends with a space, when the tag is already known or nothing matches.
+func (svc *SearchService) completeQuery(ctx context.Context, userID int64, query string) (string, error) {
+	if strings.TrimRightFunc(query, unicode.IsSpace) != query {
+		return query, nil
+	}
+
+	cut := strings.LastIndexFunc(query, func(r rune) bool {
+		return unicode.IsSpace(r) || string(r) == queryOr
+	}) + 1
+	if strings.HasPrefix(query[cut:], queryNot) {
+		return query, nil
+	}
+
+	prefix := validator.NormalizeTag(query[cut:])
+	if validator.ValidateTag(prefix) != nil {
+		return query, nil
+	}
+
+	suggestions, err := svc.photoRepo.SuggestTags(ctx, userID, prefix, constants.TagSuggestionsLimit)
+	if err != nil {
+		return query, err
+	}
+
+	if len(suggestions) == 0 || slices.Contains(suggestions, prefix) {
+		return query, nil
+	}
+
+	return query[:cut] + " " + strings.Join(suggestions, " "+queryOr+" "), nil
+}
+
+// countSearch records the outcome of a new search; turning pages of an
+// earlier one is not counted.
+func countSearch(kind string, after *model.Cursor, found int) {
+	if after != nil {
+		return
+	}
+
+	result := metrics.SearchHits
+	if found == 0 {
+		result = metrics.SearchNoResults
+	}
+	metrics.Searches.WithLabelValues(kind, result).Inc()
 }
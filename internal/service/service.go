@@ -2,23 +2,37 @@ package service
 
 import (
 	"picstagsbot/internal/domain/repo"
+	"picstagsbot/internal/storage"
 	"picstagsbot/pkg/logx"
+	"picstagsbot/pkg/validator"
 )
 
 type Service struct {
-	Reg    *RegService
-	Upload *UploadService
-	Search *SearchService
+	Reg        *RegService
+	Upload     *UploadService
+	Search     *SearchService
+	Photo      *PhotoService
+	Collection *CollectionService
+	Share      *ShareService
+	Archive    *ArchiveService
+	Export     *ExportService
+	Import     *ImportService
 }
 
-func New(repo *repo.Repo) *Service {
+func New(repo *repo.Repo, store storage.BlobStore, files FileDownloader, tagMode validator.TagMode) *Service {
 	s := &Service{}
 
+	s.Archive = NewArchiveService(repo.PhotoRepo, store, files)
 	s.Reg = NewRegService(repo.UserRepo)
-	s.Upload = NewUploadService(repo.PhotoRepo, repo.UserRepo)
+	s.Upload = NewUploadService(repo.PhotoRepo, repo.UserRepo, s.Archive, tagMode)
 	s.Search = NewSearchService(repo.PhotoRepo, repo.UserRepo)
+	s.Photo = NewPhotoService(repo.PhotoRepo, repo.UserRepo, s.Archive, tagMode)
+	s.Collection = NewCollectionService(repo.CollectionRepo, repo.UserRepo)
+	s.Share = NewShareService(repo.ShareRepo, repo.PhotoRepo, repo.UserRepo)
+	s.Export = NewExportService(repo.PhotoRepo, repo.UserRepo, files, s.Archive)
+	s.Import = NewImportService(repo.PhotoRepo, repo.UserRepo, tagMode)
 
-	logx.Info("services initialized")
+	logx.Info("services initialized", "tag_mode", tagMode, "archive_enabled", s.Archive.Enabled())
 
 	return s
 }
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/internal/domain/repo"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"picstagsbot/pkg/validator"
+	"time"
+)
+
+type ShareService struct {
+	shareRepo repo.ShareRepo
+	photoRepo repo.PhotoRepo
+	userRepo  repo.UserRepo
+}
+
+func NewShareService(shareRepo repo.ShareRepo, photoRepo repo.PhotoRepo, userRepo repo.UserRepo) *ShareService {
+	ss := &ShareService{}
+
+	ss.shareRepo = shareRepo
+	ss.photoRepo = photoRepo
+	ss.userRepo = userRepo
+
+	return ss
+}
+
+// CreateShare creates a link to the user's photos with the tag. A zero ttl
+// creates a link that never expires.
+func (svc *ShareService) CreateShare(ctx context.Context, telegramID int64, tag string, ttl time.Duration) (*model.Share, error) {
+	tag = validator.NormalizeTag(validator.SanitizeString(tag))
+	if err := validator.ValidateTag(tag); err != nil {
+		logx.Warn("invalid share tag", "telegram_id", telegramID, "tag", tag, "error", err)
+		return nil, apperrors.ValidationError(err.Error())
+	}
+
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		logx.Error("failed to generate share token", "telegram_id", telegramID, "error", err)
+		return nil, apperrors.InternalError("failed to generate share token", err)
+	}
+
+	now := time.Now().UTC()
+	share := &model.Share{
+		Token:     token,
+		OwnerID:   user.ID,
+		OwnerName: user.Username,
+		Tag:       tag,
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		share.ExpiresAt = &expiresAt
+	}
+
+	created, err := svc.shareRepo.Create(ctx, share)
+	if err != nil {
+		logx.Error("failed to create share", "telegram_id", telegramID, "user_id", user.ID, "tag", tag, "error", err)
+		return nil, apperrors.DatabaseError("failed to create share", err)
+	}
+
+	if !created {
+		logx.Warn("tag not found for share", "telegram_id", telegramID, "user_id", user.ID, "tag", tag)
+		return nil, apperrors.NotFoundError("tag not found")
+	}
+
+	logx.Info("share created", "telegram_id", telegramID, "user_id", user.ID, "share_id", share.ID, "tag", tag, "ttl", ttl)
+	return share, nil
+}
+
+// AcceptShare opens a share link. The first user other than the owner to open
+// it becomes its recipient; the link then stops working for anybody else.
+func (svc *ShareService) AcceptShare(ctx context.Context, telegramID int64, token string) (*model.Share, error) {
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	share, err := svc.shareRepo.GetByToken(ctx, token)
+	if err != nil {
+		logx.Error("failed to get share", "telegram_id", telegramID, "error", err)
+		return nil, apperrors.DatabaseError("failed to get share", err)
+	}
+
+	if share == nil || !share.IsActive(time.Now().UTC()) {
+		logx.Warn("share not found or inactive", "telegram_id", telegramID)
+		return nil, apperrors.NotFoundError("share not found")
+	}
+
+	if share.OwnerID == user.ID || share.RecipientID == user.ID {
+		return share, nil
+	}
+
+	if share.RecipientID != 0 {
+		logx.Warn("share already claimed", "telegram_id", telegramID, "share_id", share.ID)
+		return nil, apperrors.New(apperrors.ErrUnauthorized, "share already claimed")
+	}
+
+	claimed, err := svc.shareRepo.Claim(ctx, share.ID, user.ID)
+	if err != nil {
+		logx.Error("failed to claim share", "telegram_id", telegramID, "share_id", share.ID, "error", err)
+		return nil, apperrors.DatabaseError("failed to claim share", err)
+	}
+
+	if !claimed {
+		logx.Warn("share claimed concurrently", "telegram_id", telegramID, "share_id", share.ID)
+		return nil, apperrors.New(apperrors.ErrUnauthorized, "share already claimed")
+	}
+
+	share.RecipientID = user.ID
+
+	logx.Info("share accepted", "telegram_id", telegramID, "user_id", user.ID, "share_id", share.ID)
+	return share, nil
+}
+
+func (svc *ShareService) RevokeShare(ctx context.Context, telegramID, shareID int64) error {
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := svc.shareRepo.Revoke(ctx, user.ID, shareID)
+	if err != nil {
+		logx.Error("failed to revoke share", "telegram_id", telegramID, "user_id", user.ID, "share_id", shareID, "error", err)
+		return apperrors.DatabaseError("failed to revoke share", err)
+	}
+
+	if !revoked {
+		logx.Warn("share not found for revoke", "telegram_id", telegramID, "user_id", user.ID, "share_id", shareID)
+		return apperrors.NotFoundError("share not found")
+	}
+
+	logx.Info("share revoked", "telegram_id", telegramID, "user_id", user.ID, "share_id", shareID)
+	return nil
+}
+
+// ListShares returns the active shares the user has created and the ones
+// shared with them.
+func (svc *ShareService) ListShares(ctx context.Context, telegramID int64) ([]*model.Share, []*model.Share, error) {
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	owned, err := svc.shareRepo.ListByOwner(ctx, user.ID)
+	if err != nil {
+		logx.Error("failed to list owned shares", "telegram_id", telegramID, "user_id", user.ID, "error", err)
+		return nil, nil, apperrors.DatabaseError("failed to list shares", err)
+	}
+
+	received, err := svc.shareRepo.ListByRecipient(ctx, user.ID)
+	if err != nil {
+		logx.Error("failed to list received shares", "telegram_id", telegramID, "user_id", user.ID, "error", err)
+		return nil, nil, apperrors.DatabaseError("failed to list shares", err)
+	}
+
+	return activeShares(owned), activeShares(received), nil
+}
+
+// SearchSharedPhotos lists the owner's photos with the shared tag for the
+// recipient of the share.
+func (svc *ShareService) SearchSharedPhotos(ctx context.Context, telegramID, shareID int64, after *model.Cursor) (*SearchResult, error) {
+	user, err := svc.getUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	share, err := svc.shareRepo.GetByID(ctx, shareID)
+	if err != nil {
+		logx.Error("failed to get share", "telegram_id", telegramID, "share_id", shareID, "error", err)
+		return nil, apperrors.DatabaseError("failed to get share", err)
+	}
+
+	if share == nil || !share.IsActive(time.Now().UTC()) || (share.RecipientID != user.ID && share.OwnerID != user.ID) {
+		logx.Warn("share not available for user", "telegram_id", telegramID, "user_id", user.ID, "share_id", shareID)
+		return nil, apperrors.NotFoundError("share not found")
+	}
+
+	q := &model.TagQuery{All: []string{share.Tag}}
+
+	page, err := svc.photoRepo.SearchByQuery(ctx, share.OwnerID, q, model.Page{After: after, Limit: constants.SearchPageSize})
+	if err != nil {
+		logx.Error("failed to search shared photos", "telegram_id", telegramID, "share_id", shareID, "error", err)
+		return nil, apperrors.DatabaseError("failed to search photos", err)
+	}
+
+	result := &SearchResult{Photos: page.Photos, Next: page.Next}
+
+	if after == nil {
+		result.Total, err = svc.photoRepo.CountByQuery(ctx, share.OwnerID, q)
+		if err != nil {
+			logx.Error("failed to count shared photos", "telegram_id", telegramID, "share_id", shareID, "error", err)
+			return nil, apperrors.DatabaseError("failed to count photos", err)
+		}
+	}
+
+	logx.Info("shared photos searched", "telegram_id", telegramID, "user_id", user.ID, "share_id", shareID, "results_count", len(result.Photos), "total", result.Total)
+	return result, nil
+}
+
+func (svc *ShareService) getUser(ctx context.Context, telegramID int64) (*model.User, error) {
+	user, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
+	if err != nil {
+		logx.Error("failed to get user", "telegram_id", telegramID, "error", err)
+		return nil, apperrors.DatabaseError("failed to get user", err)
+	}
+
+	if user == nil {
+		logx.Warn("user not found", "telegram_id", telegramID)
+		return nil, apperrors.NotFoundError("user not found")
+	}
+
+	return user, nil
+}
+
+func activeShares(shares []*model.Share) []*model.Share {
+	now := time.Now().UTC()
+
+	active := make([]*model.Share, 0, len(shares))
+	for _, s := range shares {
+		if s.IsActive(now) {
+			active = append(active, s)
+		}
+	}
+	return active
+}
+
+// newShareToken returns a random token that fits into a /start payload.
+func newShareToken() (string, error) {
+	b := make([]byte, constants.ShareTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -6,6 +6,7 @@ import (
 	"picstagsbot/internal/domain/repo"
 	apperrors "picstagsbot/pkg/errors"
 	"picstagsbot/pkg/logx"
+	"picstagsbot/pkg/metrics"
 	"picstagsbot/pkg/validator"
 	"time"
 )
@@ -13,107 +14,132 @@ import (
 type UploadService struct {
 	photoRepo repo.PhotoRepo
 	userRepo  repo.UserRepo
+	archive   *ArchiveService
+	tagMode   validator.TagMode
 }
 
-func NewUploadService(photoRepo repo.PhotoRepo, userRepo repo.UserRepo) *UploadService {
+func NewUploadService(photoRepo repo.PhotoRepo, userRepo repo.UserRepo, archive *ArchiveService, tagMode validator.TagMode) *UploadService {
 	us := &UploadService{}
 
 	us.photoRepo = photoRepo
 	us.userRepo = userRepo
+	us.archive = archive
+	us.tagMode = tagMode
 
 	return us
 }
 
-func (svc *UploadService) CheckPhotoExists(ctx context.Context, fileID string) (bool, error) {
-	photo, err := svc.photoRepo.GetByFileID(ctx, fileID)
+func (svc *UploadService) ValidateDescription(description string) error {
+	if _, _, err := validator.ParseDescription(description, svc.tagMode); err != nil {
+		return apperrors.ValidationError(err.Error())
+	}
+	return nil
+}
+
+// CheckPhotoExists reports whether the user has already saved the photo. It
+// only lets the upload flow tell the user right away; saving stays idempotent
+// on its own, so a photo saved concurrently after the check is not stored
+// twice.
+func (svc *UploadService) CheckPhotoExists(ctx context.Context, telegramID int64, uniqueID, fileID string) (bool, error) {
+	user, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
 	if err != nil {
-		logx.Error("failed to check photo existence", "file_id", fileID, "error", err)
+		logx.Error("failed to get user for photo check", "telegram_id", telegramID, "error", err)
+		return false, apperrors.DatabaseError("failed to get user", err)
+	}
+	if user == nil {
+		logx.Warn("user not found for photo check", "telegram_id", telegramID)
+		return false, apperrors.NotFoundError("user not found")
+	}
+
+	photo, err := svc.photoRepo.GetByUniqueID(ctx, user.ID, uniqueID, fileID)
+	if err != nil {
+		logx.Error("failed to check photo existence", "telegram_id", telegramID, "file_unique_id", uniqueID, "error", err)
 		return false, apperrors.DatabaseError("failed to check photo existence", err)
 	}
 	return photo != nil, nil
 }
 
-func (svc *UploadService) UploadPhoto(ctx context.Context, telegramID int64, fileID string, fileSize int64, width, height int) (bool, error) {
+// UploadPhoto saves a photo without a description. It reports true together
+// with the stored photo when the photo has already been uploaded.
+func (svc *UploadService) UploadPhoto(ctx context.Context, telegramID int64, fileID, uniqueID string, fileSize int64, width, height int) (*model.Photo, bool, error) {
 	if err := validator.ValidateFileSize(fileSize); err != nil {
 		logx.Warn("invalid file size", "telegram_id", telegramID, "size", fileSize, "error", err)
-		return false, apperrors.ValidationError(err.Error())
-	}
-
-	existingPhoto, err := svc.photoRepo.GetByFileID(ctx, fileID)
-	if err != nil {
-		logx.Error("failed to check existing photo", "telegram_id", telegramID, "file_id", fileID, "error", err)
-		return false, apperrors.DatabaseError("failed to check existing photo", err)
-	}
-	if existingPhoto != nil {
-		logx.Info("photo already exists", "telegram_id", telegramID, "file_id", fileID)
-		return true, nil
+		return nil, false, apperrors.ValidationError(err.Error())
 	}
 
 	user, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
 	if err != nil {
 		logx.Error("failed to get user for photo upload", "telegram_id", telegramID, "error", err)
-		return false, apperrors.DatabaseError("failed to get user", err)
+		return nil, false, apperrors.DatabaseError("failed to get user", err)
 	}
 	if user == nil {
 		logx.Warn("user not found for photo upload", "telegram_id", telegramID)
-		return false, apperrors.NotFoundError("user not found")
+		return nil, false, apperrors.NotFoundError("user not found")
 	}
 
 	photo := &model.Photo{
 		UserID:     user.ID,
 		TelegramID: fileID,
+		UniqueID:   uniqueID,
 		FileSize:   fileSize,
 		Width:      width,
 		Height:     height,
 		CreatedAt:  time.Now(),
 	}
 
-	if err := svc.photoRepo.Create(ctx, photo); err != nil {
+	created, err := svc.photoRepo.Create(ctx, photo)
+	if err != nil {
 		logx.Error("failed to create photo", "telegram_id", telegramID, "user_id", user.ID, "file_id", fileID, "error", err)
-		return false, apperrors.DatabaseError("failed to create photo", err)
+		metrics.UploadedPhotos.WithLabelValues(metrics.UploadFailed).Inc()
+		return nil, false, apperrors.DatabaseError("failed to create photo", err)
+	}
+
+	if !created {
+		metrics.UploadedPhotos.WithLabelValues(metrics.UploadDuplicate).Inc()
+		logx.Info("photo already exists", "telegram_id", telegramID, "file_unique_id", uniqueID)
+		return photo, true, nil
 	}
 
+	svc.archive.Enqueue(photo)
+	metrics.UploadedPhotos.WithLabelValues(metrics.UploadSaved).Inc()
+
 	logx.Info("photo uploaded", "telegram_id", telegramID, "user_id", user.ID, "photo_id", photo.ID)
-	return false, nil
+	return photo, false, nil
+}
+
+// SavePhotoWithDescription saves a photo with its description and tags. Like
+// UploadPhoto it reports true together with the stored photo, whose
+// description is left as it was, when the photo has already been uploaded.
+func (svc *UploadService) SavePhotoWithDescription(ctx context.Context, telegramID int64, fileID, uniqueID string, fileSize int64, width, height int, description string) (*model.Photo, bool, error) {
+	return svc.savePhoto(ctx, telegramID, fileID, uniqueID, fileSize, width, height, description, svc.tagMode)
 }
 
-func (svc *UploadService) SavePhotoWithDescription(ctx context.Context, telegramID int64, fileID string, fileSize int64, width, height int, description string) (*model.Photo, error) {
+func (svc *UploadService) savePhoto(ctx context.Context, telegramID int64, fileID, uniqueID string, fileSize int64, width, height int, description string, mode validator.TagMode) (*model.Photo, bool, error) {
 	if err := validator.ValidateFileSize(fileSize); err != nil {
 		logx.Warn("invalid file size", "telegram_id", telegramID, "size", fileSize, "error", err)
-		return nil, apperrors.ValidationError(err.Error())
+		return nil, false, apperrors.ValidationError(err.Error())
 	}
 
-	tags, err := validator.ValidateAndParseTags(description)
+	description, tags, err := validator.ParseDescription(description, mode)
 	if err != nil {
 		logx.Warn("invalid description or tags", "telegram_id", telegramID, "error", err)
-		return nil, apperrors.ValidationError(err.Error())
-	}
-
-	description = validator.SanitizeString(description)
-
-	existingPhoto, err := svc.photoRepo.GetByFileID(ctx, fileID)
-	if err != nil {
-		logx.Error("failed to check existing photo with description", "telegram_id", telegramID, "file_id", fileID, "error", err)
-		return nil, apperrors.DatabaseError("failed to check existing photo", err)
-	}
-	if existingPhoto != nil {
-		logx.Info("photo with description already exists", "telegram_id", telegramID, "file_id", fileID)
-		return existingPhoto, nil
+		return nil, false, apperrors.ValidationError(err.Error())
 	}
 
 	user, err := svc.userRepo.GetByTelegramID(ctx, telegramID)
 	if err != nil {
 		logx.Error("failed to get user for photo with description", "telegram_id", telegramID, "error", err)
-		return nil, apperrors.DatabaseError("failed to get user", err)
+		return nil, false, apperrors.DatabaseError("failed to get user", err)
 	}
 	if user == nil {
 		logx.Warn("user not found for photo with description", "telegram_id", telegramID)
-		return nil, apperrors.NotFoundError("user not found")
+		return nil, false, apperrors.NotFoundError("user not found")
 	}
 
 	photo := &model.Photo{
 		UserID:      user.ID,
 		TelegramID:  fileID,
+		UniqueID:    uniqueID,
 		FileSize:    fileSize,
 		Width:       width,
 		Height:      height,
@@ -122,24 +148,33 @@ func (svc *UploadService) SavePhotoWithDescription(ctx context.Context, telegram
 		CreatedAt:   time.Now(),
 	}
 
-	if err := svc.photoRepo.Create(ctx, photo); err != nil {
+	created, err := svc.photoRepo.Create(ctx, photo)
+	if err != nil {
 		logx.Error("failed to create photo with description", "telegram_id", telegramID, "user_id", user.ID, "tags_count", len(tags), "error", err)
-		return nil, apperrors.DatabaseError("failed to create photo", err)
+		metrics.UploadedPhotos.WithLabelValues(metrics.UploadFailed).Inc()
+		return nil, false, apperrors.DatabaseError("failed to create photo", err)
 	}
 
+	if !created {
+		metrics.UploadedPhotos.WithLabelValues(metrics.UploadDuplicate).Inc()
+		logx.Info("photo with description already exists", "telegram_id", telegramID, "file_unique_id", uniqueID)
+		return photo, true, nil
+	}
+
+	svc.archive.Enqueue(photo)
+	metrics.UploadedPhotos.WithLabelValues(metrics.UploadSaved).Inc()
+
 	logx.Info("photo saved with description", "telegram_id", telegramID, "user_id", user.ID, "photo_id", photo.ID, "tags_count", len(tags))
-	return photo, nil
+	return photo, false, nil
 }
 
 func (svc *UploadService) AddDescriptionToPhoto(ctx context.Context, photoID int64, description string) error {
-	tags, err := validator.ValidateAndParseTags(description)
+	description, tags, err := validator.ParseDescription(description, svc.tagMode)
 	if err != nil {
 		logx.Warn("invalid description or tags", "photo_id", photoID, "error", err)
 		return apperrors.ValidationError(err.Error())
 	}
 
-	description = validator.SanitizeString(description)
-
 	err = svc.photoRepo.UpdateDescription(ctx, photoID, description, tags)
 	if err != nil {
 		logx.Error("failed to update photo description", "photo_id", photoID, "tags_count", len(tags), "error", err)
@@ -149,3 +184,21 @@ func (svc *UploadService) AddDescriptionToPhoto(ctx context.Context, photoID int
 	logx.Info("photo description updated", "photo_id", photoID, "tags_count", len(tags))
 	return nil
 }
+
+// IndexChatPhoto adds a photo posted in a group chat to the chat's library.
+// Only hashtags of the caption become tags unless mode says otherwise, and a
+// photo without tags is rejected with a validation error. It reports true
+// when the chat has already indexed the photo.
+func (svc *UploadService) IndexChatPhoto(ctx context.Context, chatID int64, fileID, uniqueID string, fileSize int64, width, height int, caption string, mode validator.TagMode) (*model.Photo, bool, error) {
+	_, tags, err := validator.ParseDescription(caption, mode)
+	if err != nil {
+		logx.Warn("invalid chat photo caption", "chat_id", chatID, "error", err)
+		return nil, false, apperrors.ValidationError(err.Error())
+	}
+
+	if len(tags) == 0 {
+		return nil, false, apperrors.ValidationError("no tags in caption")
+	}
+
+	return svc.savePhoto(ctx, chatID, fileID, uniqueID, fileSize, width, height, caption, mode)
+}
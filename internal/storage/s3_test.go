@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "us-east-1"
+	testBucket    = "picstags"
+)
+
+// fakeS3 is an in-memory stand-in for an S3 bucket that checks the SigV4
+// signature of every request.
+type fakeS3 struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f.verify(r); err != nil {
+		f.t.Errorf("%s %s: %v", r.Method, r.URL.Path, err)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = data
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	case http.MethodDelete:
+		if _, ok := f.objects[r.URL.Path]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) has(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.objects[path]
+	return ok
+}
+
+func (f *fakeS3) verify(r *http.Request) error {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	if got := r.Header.Get("X-Amz-Content-Sha256"); got != payloadHash {
+		return fmt.Errorf("x-amz-content-sha256 = %q, want %q", got, payloadHash)
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	if amzDate != "20240102T030405Z" {
+		return fmt.Errorf("x-amz-date = %q", amzDate)
+	}
+
+	canonical := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		"",
+		"host:" + r.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		"host;x-amz-content-sha256;x-amz-date",
+		payloadHash,
+	}, "\n")
+	canonicalSum := sha256.Sum256([]byte(canonical))
+
+	scope := "20240102/" + testRegion + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalSum[:])
+
+	key := []byte("AWS4" + testSecretKey)
+	for _, part := range []string{"20240102", testRegion, "s3", "aws4_request"} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+
+	want := fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=%s",
+		testAccessKey, scope, hex.EncodeToString(mac.Sum(nil)),
+	)
+	if got := r.Header.Get("Authorization"); got != want {
+		return fmt.Errorf("authorization = %q, want %q", got, want)
+	}
+	return nil
+}
+
+func newTestS3Store(t *testing.T) (*S3Store, *fakeS3) {
+	t.Helper()
+
+	fake := &fakeS3{t: t, objects: make(map[string][]byte)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	store, err := NewS3Store(srv.URL, testBucket, testRegion, testAccessKey, testSecretKey)
+	if err != nil {
+		t.Fatalf("NewS3Store: %v", err)
+	}
+	store.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	return store, fake
+}
+
+func TestS3StorePutGetDelete(t *testing.T) {
+	store, fake := newTestS3Store(t)
+	ctx := context.Background()
+	key := PhotoKey(7, 42)
+	data := []byte("jpeg bytes")
+
+	if err := store.Put(ctx, key, data); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if !fake.has("/" + testBucket + "/photos/7/42.jpg") {
+		t.Fatal("object not stored under a path-style key")
+	}
+
+	got, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("Get = %q, want %q", got, data)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestS3StoreNotFound(t *testing.T) {
+	store, _ := newTestS3Store(t)
+	ctx := context.Background()
+
+	if _, err := store.Get(ctx, "photos/1/missing.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get error = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, "photos/1/missing.jpg"); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+}
+
+func TestS3StoreErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	store, err := NewS3Store(srv.URL, testBucket, testRegion, testAccessKey, testSecretKey)
+	if err != nil {
+		t.Fatalf("NewS3Store: %v", err)
+	}
+
+	err = store.Put(context.Background(), "photos/1/1.jpg", []byte("x"))
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Put error = %v, want the response body in it", err)
+	}
+}
+
+func TestNewS3StoreRequiresConfig(t *testing.T) {
+	if _, err := NewS3Store("", testBucket, testRegion, testAccessKey, testSecretKey); err == nil {
+		t.Error("expected an error without an endpoint")
+	}
+	if _, err := NewS3Store("http://localhost:9000", testBucket, testRegion, "", ""); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
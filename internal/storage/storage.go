@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"picstagsbot/pkg/logx"
+)
+
+const (
+	DriverNone  = "none"
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var ErrNotFound = errors.New("storage: blob not found")
+
+// BlobStore keeps the original bytes of photos so the library survives the
+// loss of Telegram file ids.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type Config struct {
+	Driver    string
+	Dir       string
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// New returns the store selected by cfg.Driver. The "none" driver disables
+// storage and returns a nil store.
+func New(cfg Config) (BlobStore, error) {
+	switch cfg.Driver {
+	case DriverNone:
+		logx.Info("blob storage disabled")
+		return nil, nil
+	case DriverLocal:
+		return NewLocalStore(cfg.Dir)
+	case DriverS3:
+		return NewS3Store(cfg.Endpoint, cfg.Bucket, cfg.Region, cfg.AccessKey, cfg.SecretKey)
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %q", cfg.Driver)
+	}
+}
+
+// PhotoKey returns the key the photo's bytes are stored under.
+func PhotoKey(userID, photoID int64) string {
+	return fmt.Sprintf("photos/%d/%d.jpg", userID, photoID)
+}
+
+// Checksum returns the hex encoded SHA-256 of data.
+func Checksum(data []byte) string {
+	return hashHex(data)
+}
@@ -1,29 +1,79 @@
 package bot
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"picstagsbot/pkg/constants"
 	"picstagsbot/pkg/logx"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	tele "gopkg.in/telebot.v4"
 )
 
+const (
+	ModePolling = "polling"
+	ModeWebhook = "webhook"
+)
+
 type Bot struct {
-	bot *tele.Bot
+	bot     *tele.Bot
+	webhook *webhook
+	running atomic.Bool
+	errs    chan error
+}
+
+type Config struct {
+	Token         string
+	Mode          string
+	PollerTimeout time.Duration
+	WebhookURL    string
+	WebhookListen string
+	TLSCert       string
+	TLSKey        string
+	SecretToken   string
 }
 
-func New(token string, pollerTimeout time.Duration) (*Bot, error) {
+// New creates a bot that receives updates by long polling or, in webhook
+// mode, through an HTTP server listening on cfg.WebhookListen.
+func New(cfg Config) (*Bot, error) {
 	bot := &Bot{}
+	bot.errs = make(chan error, 1)
+
+	var poller tele.Poller
+	switch cfg.Mode {
+	case ModePolling:
+		poller = &tele.LongPoller{Timeout: cfg.PollerTimeout}
+	case ModeWebhook:
+		wh, err := newWebhook(cfg)
+		if err != nil {
+			return nil, err
+		}
+		bot.webhook = wh
+		poller = wh.poller
+	default:
+		return nil, fmt.Errorf("unknown bot mode: %q", cfg.Mode)
+	}
 
 	tb, err := tele.NewBot(tele.Settings{
-		Token:  token,
-		Poller: &tele.LongPoller{Timeout: pollerTimeout},
+		Token:  cfg.Token,
+		Poller: poller,
+		Client: &http.Client{
+			Timeout:   constants.BotClientTimeout,
+			Transport: &pollWatcher{next: http.DefaultTransport, running: &bot.running},
+		},
 	})
 	if err != nil {
 		return nil, err
 	}
 	bot.bot = tb
 
-	logx.Info("telegram bot initialized", "poller_timeout", pollerTimeout)
+	logx.Info("telegram bot initialized", "mode", cfg.Mode, "poller_timeout", cfg.PollerTimeout)
 
 	return bot, nil
 }
@@ -32,12 +82,113 @@ func (b *Bot) Bot() *tele.Bot {
 	return b.bot
 }
 
+// FileUniqueID asks getFile for the file_unique_id behind a file_id.
+func (b *Bot) FileUniqueID(ctx context.Context, fileID string) (string, error) {
+	file, err := b.bot.FileByID(fileID)
+	if err != nil {
+		return "", err
+	}
+	return file.UniqueID, nil
+}
+
+// Download fetches the contents of a file through getFile.
+func (b *Bot) Download(ctx context.Context, fileID string) ([]byte, error) {
+	file, err := b.bot.FileByID(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	fileURL := b.bot.URL + "/file/bot" + b.bot.Token + "/" + file.FilePath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The URL carries the bot token, keep it out of the error.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, fmt.Errorf("file download failed: %w", urlErr.Err)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("file download responded %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, constants.MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > constants.MaxFileSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", constants.MaxFileSize)
+	}
+
+	return data, nil
+}
+
 func (b *Bot) Start() {
+	if b.webhook != nil {
+		go func() {
+			if err := b.webhook.serve(b.bot, &b.running); err != nil {
+				b.fail(err)
+			}
+		}()
+	}
+
+	defer b.running.Store(false)
+
 	logx.Info("telegram bot started")
 	b.bot.Start()
 }
 
-func (b *Bot) Stop() {
+// Errors reports failures after which the bot can no longer receive
+// updates, such as the webhook server failing to listen.
+func (b *Bot) Errors() <-chan error {
+	return b.errs
+}
+
+func (b *Bot) fail(err error) {
+	select {
+	case b.errs <- err:
+	default:
+	}
+}
+
+// Running reports whether the bot is receiving updates: the last getUpdates
+// call succeeded or the webhook server is listening.
+func (b *Bot) Running() bool {
+	return b.running.Load()
+}
+
+// Stop stops receiving updates. In webhook mode the HTTP server is given
+// until ctx is done to finish the requests in flight.
+func (b *Bot) Stop(ctx context.Context) {
+	b.running.Store(false)
+
+	if b.webhook != nil {
+		b.webhook.shutdown(ctx)
+	}
+
 	b.bot.Stop()
 	logx.Info("telegram bot stopped")
 }
+
+// pollWatcher marks the bot running while getUpdates succeeds. telebot
+// retries failed polls silently, so a revoked token or a second instance
+// polling with the same token would otherwise go unnoticed.
+type pollWatcher struct {
+	next    http.RoundTripper
+	running *atomic.Bool
+}
+
+func (w *pollWatcher) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := w.next.RoundTrip(req)
+	if strings.HasSuffix(req.URL.Path, "/getUpdates") {
+		w.running.Store(err == nil && resp.StatusCode == http.StatusOK)
+	}
+	return resp, err
+}
@@ -0,0 +1,162 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"picstagsbot/pkg/constants"
+	"picstagsbot/pkg/logx"
+	"regexp"
+	"sync/atomic"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+var secretTokenRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// webhook receives updates over HTTP. The tele.Webhook poller is only used
+// to describe the webhook to Telegram: it neither listens nor calls
+// setWebhook by itself, both are done here so that requests with a wrong
+// secret token are refused and the server shuts down gracefully.
+type webhook struct {
+	poller  *tele.Webhook
+	server  *http.Server
+	tlsCert string
+	tlsKey  string
+}
+
+func newWebhook(cfg Config) (*webhook, error) {
+	publicURL, err := url.Parse(cfg.WebhookURL)
+	if err != nil || publicURL.Scheme != "https" || publicURL.Host == "" {
+		return nil, fmt.Errorf("webhook url must be an https url, got %q", cfg.WebhookURL)
+	}
+	if !secretTokenRegex.MatchString(cfg.SecretToken) {
+		return nil, fmt.Errorf("webhook secret token must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+	}
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return nil, fmt.Errorf("webhook tls needs both a cert and a key")
+	}
+
+	wh := &webhook{}
+	wh.poller = &tele.Webhook{
+		SecretToken:      cfg.SecretToken,
+		IgnoreSetWebhook: true,
+		Endpoint:         &tele.WebhookEndpoint{PublicURL: cfg.WebhookURL},
+	}
+	wh.server = &http.Server{
+		Addr:              cfg.WebhookListen,
+		ReadHeaderTimeout: constants.WebhookReadTimeout,
+		ReadTimeout:       constants.WebhookReadTimeout,
+		WriteTimeout:      constants.WebhookReadTimeout,
+	}
+	wh.tlsCert = cfg.TLSCert
+	wh.tlsKey = cfg.TLSKey
+
+	return wh, nil
+}
+
+// serve runs the webhook server until it is shut down. Any other outcome,
+// such as the address being in use, is returned. running is set while the
+// listener is bound.
+func (wh *webhook) serve(tb *tele.Bot, running *atomic.Bool) error {
+	wh.server.Handler = wh.handler(tb)
+
+	ln, err := net.Listen("tcp", wh.server.Addr)
+	if err != nil {
+		logx.Error("webhook server failed to listen", "addr", wh.server.Addr, "error", err)
+		return fmt.Errorf("webhook server: %w", err)
+	}
+
+	running.Store(true)
+	defer running.Store(false)
+
+	logx.Info("webhook server listening", "addr", wh.server.Addr, "tls", wh.tlsCert != "")
+
+	if wh.tlsCert != "" {
+		err = wh.server.ServeTLS(ln, wh.tlsCert, wh.tlsKey)
+	} else {
+		err = wh.server.Serve(ln)
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logx.Error("webhook server failed", "error", err)
+		return fmt.Errorf("webhook server: %w", err)
+	}
+	return nil
+}
+
+func (wh *webhook) shutdown(ctx context.Context) {
+	if err := wh.server.Shutdown(ctx); err != nil {
+		logx.Warn("webhook server shutdown failed", "error", err)
+	}
+}
+
+// handler checks the secret token Telegram sends with every request and
+// passes the update to the bot's update loop.
+func (wh *webhook) handler(tb *tele.Bot) http.Handler {
+	secret := []byte(wh.poller.SecretToken)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), secret) != 1 {
+			logx.Warn("webhook request with invalid secret token", "remote_addr", r.RemoteAddr)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var update tele.Update
+		if err := json.NewDecoder(io.LimitReader(r.Body, constants.MaxWebhookBodySize)).Decode(&update); err != nil {
+			logx.Warn("invalid webhook update", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		select {
+		case tb.Updates <- update:
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// SetWebhook registers the public URL and the secret token with Telegram.
+// It does nothing in polling mode.
+func (b *Bot) SetWebhook() error {
+	if b.webhook == nil {
+		return nil
+	}
+
+	if err := b.bot.SetWebhook(b.webhook.poller); err != nil {
+		return fmt.Errorf("set webhook: %w", err)
+	}
+
+	logx.Info("webhook registered", "url", b.webhook.poller.Endpoint.PublicURL)
+	return nil
+}
+
+// DeleteWebhook unregisters the webhook so another instance can take over.
+// It does nothing in polling mode.
+func (b *Bot) DeleteWebhook() error {
+	if b.webhook == nil {
+		return nil
+	}
+
+	if err := b.bot.RemoveWebhook(); err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
+	logx.Info("webhook deleted")
+	return nil
+}
@@ -0,0 +1,117 @@
+package collection
+
+import (
+	"picstagsbot/internal/service"
+	"picstagsbot/pkg/constants"
+	"strconv"
+	"sync"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// NameSession waits for the name of a new collection, or for a new name of
+// the collection with CollectionID when it is set.
+type NameSession struct {
+	CollectionID int64
+	LastActivity time.Time
+}
+
+type CollectionHandler struct {
+	collectionService *service.CollectionService
+	nameSessions      map[int64]*NameSession
+	mu                sync.RWMutex
+	stopCleanup       chan struct{}
+}
+
+func NewCollectionHandler(collectionService *service.CollectionService) *CollectionHandler {
+	ch := &CollectionHandler{
+		collectionService: collectionService,
+		nameSessions:      make(map[int64]*NameSession),
+		stopCleanup:       make(chan struct{}),
+	}
+
+	go ch.cleanupSessions()
+
+	return ch
+}
+
+func (h *CollectionHandler) clearSession(userID int64) {
+	h.mu.Lock()
+	delete(h.nameSessions, userID)
+	h.mu.Unlock()
+}
+
+func (h *CollectionHandler) getSession(userID int64) (int64, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	session, ok := h.nameSessions[userID]
+	if !ok {
+		return 0, false
+	}
+	return session.CollectionID, true
+}
+
+func (h *CollectionHandler) setSession(userID, collectionID int64) {
+	h.mu.Lock()
+	h.nameSessions[userID] = &NameSession{
+		CollectionID: collectionID,
+		LastActivity: time.Now(),
+	}
+	h.mu.Unlock()
+}
+
+func (h *CollectionHandler) cleanupSessions() {
+	ticker := time.NewTicker(constants.SessionCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.mu.Lock()
+			now := time.Now()
+			for userID, session := range h.nameSessions {
+				if now.Sub(session.LastActivity) > constants.SessionTimeout {
+					delete(h.nameSessions, userID)
+				}
+			}
+			h.mu.Unlock()
+		case <-h.stopCleanup:
+			return
+		}
+	}
+}
+
+func (h *CollectionHandler) Stop() {
+	close(h.stopCleanup)
+}
+
+func (h *CollectionHandler) IsNamingSession(userID int64) bool {
+	_, ok := h.getSession(userID)
+	return ok
+}
+
+func idFromCallback(c tele.Context) (int64, error) {
+	return strconv.ParseInt(c.Data(), 10, 64)
+}
+
+// idsFromCallback parses "<collection id>|<ref>" callback data.
+func idsFromCallback(c tele.Context) (int64, int64, error) {
+	args := c.Args()
+	if len(args) != 2 {
+		return 0, 0, strconv.ErrSyntax
+	}
+
+	collectionID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ref, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return collectionID, ref, nil
+}
@@ -0,0 +1,249 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"picstagsbot/internal/tg/keyboard"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+func (h *CollectionHandler) HandleCollections(c tele.Context) error {
+	userID := c.Sender().ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	collections, err := h.collectionService.ListCollections(ctx, userID)
+	if err != nil {
+		logx.Error("list collections failed", "telegram_id", userID, "error", err)
+		return message.SendWithEmoji(c, message.EmojiCollectionError, message.MsgCollectionError, keyboard.MainMenu)
+	}
+
+	logx.Info("collections listed", "telegram_id", userID, "collections_count", len(collections))
+
+	if len(collections) == 0 {
+		return message.SendWithEmoji(c, message.EmojiCollections, message.MsgNoCollections, keyboard.CollectionList(nil))
+	}
+
+	return message.SendWithEmoji(c, message.EmojiCollections, message.MsgCollections, keyboard.CollectionList(collections))
+}
+
+func (h *CollectionHandler) HandleCollectionNew(c tele.Context) error {
+	userID := c.Sender().ID
+
+	logx.Info("collection create started", "telegram_id", userID)
+	h.setSession(userID, 0)
+
+	_ = c.Respond()
+	return message.SendWithEmoji(c, message.EmojiCollectionName, message.MsgCollectionName)
+}
+
+func (h *CollectionHandler) HandleCollectionOpen(c tele.Context) error {
+	userID := c.Sender().ID
+
+	collectionID, err := idFromCallback(c)
+	if err != nil {
+		logx.Warn("invalid collection callback data", "telegram_id", userID, "data", c.Data())
+		return c.RespondAlert(message.MsgCollectionNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	col, err := h.collectionService.GetCollection(ctx, userID, collectionID)
+	if err != nil {
+		return respondCollectionError(c, err)
+	}
+
+	_ = c.Respond()
+	return c.Send(fmt.Sprintf(message.MsgCollectionInfo, col.Name, col.PhotoCount), keyboard.CollectionActions(col.ID))
+}
+
+func (h *CollectionHandler) HandleCollectionRename(c tele.Context) error {
+	userID := c.Sender().ID
+
+	collectionID, err := idFromCallback(c)
+	if err != nil {
+		logx.Warn("invalid collection rename callback data", "telegram_id", userID, "data", c.Data())
+		return c.RespondAlert(message.MsgCollectionNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	col, err := h.collectionService.GetCollection(ctx, userID, collectionID)
+	if err != nil {
+		return respondCollectionError(c, err)
+	}
+
+	logx.Info("collection rename started", "telegram_id", userID, "collection_id", col.ID)
+	h.setSession(userID, col.ID)
+
+	_ = c.Respond()
+	return message.SendWithEmoji(c, message.EmojiCollectionName, fmt.Sprintf(message.MsgCollectionRename, col.Name))
+}
+
+func (h *CollectionHandler) HandleNameText(c tele.Context) error {
+	userID := c.Sender().ID
+
+	collectionID, ok := h.getSession(userID)
+	if !ok {
+		return nil
+	}
+
+	name := c.Text()
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	var err error
+	var reply string
+	if collectionID == 0 {
+		col, createErr := h.collectionService.CreateCollection(ctx, userID, name)
+		err = createErr
+		if err == nil {
+			reply = fmt.Sprintf(message.MsgCollectionCreated, col.Name)
+		}
+	} else {
+		err = h.collectionService.RenameCollection(ctx, userID, collectionID, name)
+		reply = fmt.Sprintf(message.MsgCollectionRenamed, name)
+	}
+
+	switch {
+	case errors.Is(err, apperrors.ErrValidation):
+		return message.SendWithEmoji(c, message.EmojiCollectionInvalidName, message.MsgCollectionInvalidName)
+	case errors.Is(err, apperrors.ErrAlreadyExists):
+		return message.SendWithEmoji(c, message.EmojiCollectionExists, message.MsgCollectionExists)
+	}
+
+	h.clearSession(userID)
+
+	if err != nil {
+		logx.Error("collection naming failed", "telegram_id", userID, "collection_id", collectionID, "error", err)
+		return message.SendWithEmoji(c, message.EmojiCollectionError, message.MsgCollectionError, keyboard.MainMenu)
+	}
+
+	if collectionID == 0 {
+		return message.SendWithEmoji(c, message.EmojiCollectionCreated, reply, keyboard.MainMenu)
+	}
+	return message.SendWithEmoji(c, message.EmojiCollectionRenamed, reply, keyboard.MainMenu)
+}
+
+func (h *CollectionHandler) HandleCollectionDelete(c tele.Context) error {
+	userID := c.Sender().ID
+
+	collectionID, err := idFromCallback(c)
+	if err != nil {
+		logx.Warn("invalid collection delete callback data", "telegram_id", userID, "data", c.Data())
+		return c.RespondAlert(message.MsgCollectionNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	col, err := h.collectionService.GetCollection(ctx, userID, collectionID)
+	if err != nil {
+		return respondCollectionError(c, err)
+	}
+
+	_ = c.Respond()
+	return c.Edit(fmt.Sprintf(message.MsgCollectionDeleteConfirm, col.Name), keyboard.CollectionDeleteConfirm(col.ID))
+}
+
+func (h *CollectionHandler) HandleCollectionDeleteConfirm(c tele.Context) error {
+	userID := c.Sender().ID
+
+	collectionID, err := idFromCallback(c)
+	if err != nil {
+		logx.Warn("invalid collection delete confirm callback data", "telegram_id", userID, "data", c.Data())
+		return c.RespondAlert(message.MsgCollectionNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	if err := h.collectionService.DeleteCollection(ctx, userID, collectionID); err != nil {
+		return respondCollectionError(c, err)
+	}
+
+	_ = c.Respond()
+	return c.Edit(message.MsgCollectionDeleted)
+}
+
+func (h *CollectionHandler) HandleCollectionDeleteCancel(c tele.Context) error {
+	_ = c.Delete()
+	return c.RespondText(message.MsgCollectionDeleteCancel)
+}
+
+// HandlePhotoPick asks which collection a photo from search results should
+// be added to.
+func (h *CollectionHandler) HandlePhotoPick(c tele.Context) error {
+	userID := c.Sender().ID
+
+	photoID, err := idFromCallback(c)
+	if err != nil {
+		logx.Warn("invalid collection pick callback data", "telegram_id", userID, "data", c.Data())
+		return c.RespondAlert(message.MsgPhotoNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	collections, err := h.collectionService.ListCollections(ctx, userID)
+	if err != nil {
+		return respondCollectionError(c, err)
+	}
+
+	if len(collections) == 0 {
+		return c.RespondAlert(message.MsgCollectionPickNone)
+	}
+
+	_ = c.Respond()
+	return c.Send(message.MsgCollectionPick, keyboard.CollectionChoice(keyboard.BtnCollectionAddPhoto, collections, photoID))
+}
+
+func (h *CollectionHandler) HandleAddPhoto(c tele.Context) error {
+	userID := c.Sender().ID
+
+	collectionID, photoID, err := idsFromCallback(c)
+	if err != nil {
+		logx.Warn("invalid collection add callback data", "telegram_id", userID, "data", c.Data())
+		return c.RespondAlert(message.MsgCollectionNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	col, err := h.collectionService.GetCollection(ctx, userID, collectionID)
+	if err != nil {
+		return respondCollectionError(c, err)
+	}
+
+	added, err := h.collectionService.AddPhotos(ctx, userID, col.ID, []int64{photoID})
+	if err != nil {
+		return respondCollectionError(c, err)
+	}
+
+	_ = c.Respond()
+	return c.Edit(fmt.Sprintf(message.MsgCollectionPhotosAdded, col.Name, added))
+}
+
+func (h *CollectionHandler) HandlePickCancel(c tele.Context) error {
+	_ = c.Delete()
+	return c.RespondText(message.MsgCollectionPickCancel)
+}
+
+func respondCollectionError(c tele.Context, err error) error {
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return c.RespondAlert(message.MsgCollectionNotFound)
+	}
+
+	logx.Error("collection action failed", "telegram_id", c.Sender().ID, "error", err)
+	return c.RespondAlert(message.MsgCollectionError)
+}
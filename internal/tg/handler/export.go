@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"picstagsbot/internal/service"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"sync"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+type ExportHandler struct {
+	exportService *service.ExportService
+	running       map[int64]bool
+	mu            sync.Mutex
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+func NewExportHandler(exportService *service.ExportService) *ExportHandler {
+	eh := &ExportHandler{}
+
+	eh.exportService = exportService
+	eh.running = make(map[int64]bool)
+	eh.ctx, eh.cancel = context.WithCancel(context.Background())
+
+	return eh
+}
+
+// Stop cancels the exports in progress and waits until they have cleaned up
+// their temporary archives or ctx is done.
+func (h *ExportHandler) Stop(ctx context.Context) {
+	h.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logx.Warn("export shutdown timeout exceeded")
+	}
+}
+
+// HandleExport starts building the user's archive in the background. Only
+// one export per user runs at a time.
+func (h *ExportHandler) HandleExport(c tele.Context) error {
+	userID := c.Sender().ID
+
+	if !h.start(userID) {
+		return message.SendWithEmoji(c, message.EmojiExportRunning, message.MsgExportRunning)
+	}
+
+	logx.Info("export started", "telegram_id", userID)
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		defer h.finish(userID)
+		h.export(c, userID)
+	}()
+
+	return message.SendWithEmoji(c, message.EmojiExportStarted, message.MsgExportStarted)
+}
+
+func (h *ExportHandler) export(c tele.Context, userID int64) {
+	ctx, cancel := context.WithTimeout(h.ctx, constants.ExportTimeout)
+	defer cancel()
+
+	result, err := h.exportService.Export(ctx, userID, func(part *service.ExportPart) error {
+		doc := &tele.Document{
+			File:     tele.FromDisk(part.Path),
+			FileName: part.Name,
+			MIME:     "application/zip",
+			Caption:  fmt.Sprintf(message.MsgExportPart, part.Number, part.Photos),
+		}
+		return c.Send(doc)
+	})
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			_ = message.SendWithEmoji(c, message.EmojiExportEmpty, message.MsgExportEmpty)
+			return
+		}
+		logx.Error("export failed", "telegram_id", userID, "error", err)
+		_ = message.SendWithEmoji(c, message.EmojiExportError, message.MsgExportError)
+		return
+	}
+
+	if result.Parts == 0 && result.Failed == 0 {
+		_ = message.SendWithEmoji(c, message.EmojiExportEmpty, message.MsgExportEmpty)
+		return
+	}
+
+	text := fmt.Sprintf(message.MsgExportDone, result.Photos, result.Parts)
+	if result.Failed > 0 {
+		text += fmt.Sprintf(message.MsgExportFailed, result.Failed)
+	}
+	_ = message.SendWithEmoji(c, message.EmojiExportDone, text)
+}
+
+func (h *ExportHandler) start(userID int64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.running[userID] {
+		return false
+	}
+	h.running[userID] = true
+	return true
+}
+
+func (h *ExportHandler) finish(userID int64) {
+	h.mu.Lock()
+	delete(h.running, userID)
+	h.mu.Unlock()
+}
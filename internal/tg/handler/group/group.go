@@ -0,0 +1,105 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/internal/service"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"picstagsbot/pkg/validator"
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// GroupHandler indexes photos posted in group chats into a library owned by
+// the chat.
+type GroupHandler struct {
+	regService    *service.RegService
+	uploadService *service.UploadService
+}
+
+func NewGroupHandler(regService *service.RegService, uploadService *service.UploadService) *GroupHandler {
+	gh := &GroupHandler{}
+
+	gh.regService = regService
+	gh.uploadService = uploadService
+
+	return gh
+}
+
+// HandlePhoto indexes a photo whose caption has hashtags. Photos without
+// hashtags are ordinary chat messages and are silently ignored. The bot only
+// sees them when privacy mode is disabled.
+func (h *GroupHandler) HandlePhoto(c tele.Context) error {
+	msg := c.Message()
+	if msg.Photo == nil || !strings.Contains(msg.Caption, "#") {
+		return nil
+	}
+
+	_, _, err := h.index(c, msg, msg.Caption, validator.TagModeHashtags)
+	if err != nil && !errors.Is(err, apperrors.ErrValidation) {
+		logx.Error("group photo index failed", "chat_id", c.Chat().ID, "telegram_id", c.Sender().ID, "error", err)
+	}
+	return nil
+}
+
+// HandleSave serves "/save [tags]" sent as a reply to a photo. Commands
+// reach the bot in privacy mode too, so this is how photos get into the
+// library when the bot cannot see every message. Without arguments the
+// hashtags of the photo caption are used.
+func (h *GroupHandler) HandleSave(c tele.Context) error {
+	reply := c.Message().ReplyTo
+	if reply == nil || reply.Photo == nil {
+		return c.Reply(message.MsgGroupSaveUsage)
+	}
+
+	description, mode := reply.Caption, validator.TagModeHashtags
+	if payload := strings.TrimSpace(c.Message().Payload); payload != "" {
+		description, mode = payload, validator.TagModeWords
+	}
+
+	p, existing, err := h.index(c, reply, description, mode)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrValidation) {
+			return c.Reply(message.MsgGroupSaveNoTags)
+		}
+		logx.Error("group photo save failed", "chat_id", c.Chat().ID, "telegram_id", c.Sender().ID, "error", err)
+		return c.Reply(message.MsgPhotoSaveError)
+	}
+
+	if existing {
+		return c.Reply(message.MsgGroupAlreadySaved + message.Hashtags(p.Tags))
+	}
+	return c.Reply(message.MsgGroupSaved + message.Hashtags(p.Tags))
+}
+
+func (h *GroupHandler) HandleHelp(c tele.Context) error {
+	return c.Send(message.MsgGroupHelp)
+}
+
+func (h *GroupHandler) index(c tele.Context, msg *tele.Message, description string, mode validator.TagMode) (*model.Photo, bool, error) {
+	chat := c.Chat()
+	photo := msg.Photo
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	if _, err := h.regService.RegisterChat(ctx, chat.ID, chat.Title); err != nil {
+		return nil, false, err
+	}
+
+	p, existing, err := h.uploadService.IndexChatPhoto(ctx, chat.ID, photo.FileID, photo.UniqueID, int64(photo.FileSize), photo.Width, photo.Height, description, mode)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing {
+		return p, true, nil
+	}
+
+	logx.Info("group photo indexed", "chat_id", chat.ID, "telegram_id", c.Sender().ID, "photo_id", p.ID, "tags_count", len(p.Tags))
+	return p, false, nil
+}
@@ -2,27 +2,42 @@ package handler
 
 import (
 	"picstagsbot/internal/service"
+	"picstagsbot/internal/tg/handler/collection"
+	"picstagsbot/internal/tg/handler/group"
+	"picstagsbot/internal/tg/handler/photo"
 	"picstagsbot/internal/tg/handler/search"
 	"picstagsbot/internal/tg/handler/upload"
 	"picstagsbot/pkg/logx"
 )
 
 type Handler struct {
-	Reg    *RegHandler
-	Help   *HelpHandler
-	Info   *InfoHandler
-	Upload *upload.UploadHandler
-	Search *search.SearchHandler
+	Reg        *RegHandler
+	Help       *HelpHandler
+	Info       *InfoHandler
+	Upload     *upload.UploadHandler
+	Search     *search.SearchHandler
+	Photo      *photo.PhotoHandler
+	Collection *collection.CollectionHandler
+	Share      *ShareHandler
+	Group      *group.GroupHandler
+	Export     *ExportHandler
+	Import     *ImportHandler
 }
 
 func New(svc *service.Service) *Handler {
 	h := &Handler{}
 
-	h.Reg = NewRegHandler(svc.Reg)
+	h.Reg = NewRegHandler(svc.Reg, svc.Share)
 	h.Help = NewHelpHandler()
 	h.Info = NewInfoHandler()
-	h.Upload = upload.NewUploadHandler(svc.Upload)
-	h.Search = search.NewSearchHandler(svc.Search)
+	h.Upload = upload.NewUploadHandler(svc.Upload, svc.Collection)
+	h.Search = search.NewSearchHandler(svc.Search, svc.Collection, svc.Share, svc.Archive)
+	h.Photo = photo.NewPhotoHandler(svc.Photo, svc.Archive)
+	h.Collection = collection.NewCollectionHandler(svc.Collection)
+	h.Share = NewShareHandler(svc.Share)
+	h.Group = group.NewGroupHandler(svc.Reg, svc.Upload)
+	h.Export = NewExportHandler(svc.Export)
+	h.Import = NewImportHandler(svc.Import)
 
 	logx.Info("handlers initialized")
 
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"picstagsbot/internal/service"
+	"picstagsbot/internal/tg/keyboard"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+type ImportSession struct {
+	ID           int64
+	Plan         *service.ImportPlan
+	LastActivity time.Time
+}
+
+type ImportHandler struct {
+	importService *service.ImportService
+	sessions      map[int64]*ImportSession
+	nextID        int64
+	mu            sync.Mutex
+	stopCleanup   chan struct{}
+}
+
+func NewImportHandler(importService *service.ImportService) *ImportHandler {
+	ih := &ImportHandler{}
+
+	ih.importService = importService
+	ih.sessions = make(map[int64]*ImportSession)
+	ih.stopCleanup = make(chan struct{})
+
+	go ih.cleanupSessions()
+
+	return ih
+}
+
+// HandleDocument checks an uploaded CSV or JSON file and shows what applying
+// it would change. Nothing is stored until the user confirms.
+func (h *ImportHandler) HandleDocument(c tele.Context) error {
+	userID := c.Sender().ID
+	doc := c.Message().Document
+
+	switch strings.ToLower(path.Ext(doc.FileName)) {
+	case ".csv", ".json":
+	default:
+		return message.SendWithEmoji(c, message.EmojiImportUnsupported, message.MsgImportUnsupported)
+	}
+
+	if doc.FileSize > constants.MaxImportFileSize {
+		return message.SendWithEmoji(c, message.EmojiImportTooLarge, fmt.Sprintf(message.MsgImportTooLarge, constants.MaxImportFileSize>>20))
+	}
+
+	data, err := h.download(c, doc)
+	if err != nil {
+		logx.Error("failed to download import file", "telegram_id", userID, "error", err)
+		return message.SendWithEmoji(c, message.EmojiImportError, message.MsgImportError)
+	}
+	if len(data) > constants.MaxImportFileSize {
+		return message.SendWithEmoji(c, message.EmojiImportTooLarge, fmt.Sprintf(message.MsgImportTooLarge, constants.MaxImportFileSize>>20))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	plan, err := h.importService.Plan(ctx, userID, doc.FileName, data)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrValidation) {
+			return message.SendWithEmoji(c, message.EmojiImportInvalid, message.MsgImportInvalid)
+		}
+		return message.SendWithEmoji(c, message.EmojiImportError, message.MsgImportError)
+	}
+
+	text := fmt.Sprintf(message.MsgImportPlan, plan.Rows, len(plan.Edits), len(plan.Unknown), len(plan.Invalid))
+	if len(plan.Unknown) > 0 {
+		text += fmt.Sprintf(message.MsgImportRows, message.MsgImportUnknownRows, formatRows(plan.Unknown))
+	}
+	if len(plan.Invalid) > 0 {
+		text += fmt.Sprintf(message.MsgImportRows, message.MsgImportInvalidRows, formatRows(plan.Invalid))
+	}
+
+	if len(plan.Edits) == 0 {
+		h.clearSession(userID)
+		return message.SendWithEmoji(c, message.EmojiImportPlan, text+message.MsgImportNothing)
+	}
+
+	importID := h.setSession(userID, plan)
+	return message.SendWithEmoji(c, message.EmojiImportPlan, text+message.MsgImportConfirm, keyboard.ImportConfirm(importID))
+}
+
+// HandleApply stores the checked import. The id in the callback data makes
+// sure an old keyboard does not apply a newer file.
+func (h *ImportHandler) HandleApply(c tele.Context) error {
+	userID := c.Sender().ID
+
+	plan, ok := h.takeSession(userID, c.Data())
+	if !ok {
+		_ = c.Respond()
+		return c.Edit(message.MsgImportExpired)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	updated, err := h.importService.Apply(ctx, userID, plan)
+	if err != nil {
+		logx.Error("import failed", "telegram_id", userID, "error", err)
+		return c.RespondAlert(message.MsgImportError)
+	}
+
+	_ = c.Respond()
+	return c.Edit(fmt.Sprintf(message.MsgImportApplied, updated))
+}
+
+func (h *ImportHandler) HandleCancel(c tele.Context) error {
+	h.takeSession(c.Sender().ID, c.Data())
+
+	_ = c.Respond()
+	return c.Edit(message.MsgImportCanceled)
+}
+
+func (h *ImportHandler) download(c tele.Context, doc *tele.Document) ([]byte, error) {
+	rc, err := c.Bot().File(&doc.File)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(io.LimitReader(rc, constants.MaxImportFileSize+1))
+}
+
+func (h *ImportHandler) setSession(userID int64, plan *service.ImportPlan) int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	h.sessions[userID] = &ImportSession{
+		ID:           h.nextID,
+		Plan:         plan,
+		LastActivity: time.Now(),
+	}
+	return h.nextID
+}
+
+// takeSession removes and returns the user's pending import if it is the one
+// the callback data refers to.
+func (h *ImportHandler) takeSession(userID int64, data string) (*service.ImportPlan, bool) {
+	importID, err := strconv.ParseInt(data, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[userID]
+	if !ok || session.ID != importID {
+		return nil, false
+	}
+
+	delete(h.sessions, userID)
+	return session.Plan, true
+}
+
+func (h *ImportHandler) clearSession(userID int64) {
+	h.mu.Lock()
+	delete(h.sessions, userID)
+	h.mu.Unlock()
+}
+
+func (h *ImportHandler) cleanupSessions() {
+	ticker := time.NewTicker(constants.SessionCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.mu.Lock()
+			now := time.Now()
+			for userID, session := range h.sessions {
+				if now.Sub(session.LastActivity) > constants.SessionTimeout {
+					delete(h.sessions, userID)
+				}
+			}
+			h.mu.Unlock()
+		case <-h.stopCleanup:
+			return
+		}
+	}
+}
+
+func (h *ImportHandler) Stop() {
+	close(h.stopCleanup)
+}
+
+// formatRows lists the first constants.ImportReportRows row numbers.
+func formatRows(rows []int) string {
+	n := min(len(rows), constants.ImportReportRows)
+
+	parts := make([]string, 0, n+1)
+	for _, row := range rows[:n] {
+		parts = append(parts, strconv.Itoa(row))
+	}
+	if len(rows) > n {
+		parts = append(parts, fmt.Sprintf("… +%d", len(rows)-n))
+	}
+
+	return strings.Join(parts, ", ")
+}
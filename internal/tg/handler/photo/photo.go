@@ -0,0 +1,92 @@
+package photo
+
+import (
+	"picstagsbot/internal/service"
+	"picstagsbot/pkg/constants"
+	"strconv"
+	"sync"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+type EditSession struct {
+	PhotoID      int64
+	LastActivity time.Time
+}
+
+type PhotoHandler struct {
+	photoService   *service.PhotoService
+	archiveService *service.ArchiveService
+	editSessions   map[int64]*EditSession
+	mu             sync.RWMutex
+	stopCleanup    chan struct{}
+}
+
+func NewPhotoHandler(photoService *service.PhotoService, archiveService *service.ArchiveService) *PhotoHandler {
+	ph := &PhotoHandler{
+		photoService:   photoService,
+		archiveService: archiveService,
+		editSessions:   make(map[int64]*EditSession),
+		stopCleanup:    make(chan struct{}),
+	}
+
+	go ph.cleanupSessions()
+
+	return ph
+}
+
+func (h *PhotoHandler) clearSession(userID int64) {
+	h.mu.Lock()
+	delete(h.editSessions, userID)
+	h.mu.Unlock()
+}
+
+func (h *PhotoHandler) getSession(userID int64) (int64, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	session, ok := h.editSessions[userID]
+	if !ok {
+		return 0, false
+	}
+	return session.PhotoID, true
+}
+
+func (h *PhotoHandler) setSession(userID, photoID int64) {
+	h.mu.Lock()
+	h.editSessions[userID] = &EditSession{
+		PhotoID:      photoID,
+		LastActivity: time.Now(),
+	}
+	h.mu.Unlock()
+}
+
+func (h *PhotoHandler) cleanupSessions() {
+	ticker := time.NewTicker(constants.SessionCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.mu.Lock()
+			now := time.Now()
+			for userID, session := range h.editSessions {
+				if now.Sub(session.LastActivity) > constants.SessionTimeout {
+					delete(h.editSessions, userID)
+				}
+			}
+			h.mu.Unlock()
+		case <-h.stopCleanup:
+			return
+		}
+	}
+}
+
+func (h *PhotoHandler) Stop() {
+	close(h.stopCleanup)
+}
+
+func photoIDFromCallback(c tele.Context) (int64, error) {
+	return strconv.ParseInt(c.Data(), 10, 64)
+}
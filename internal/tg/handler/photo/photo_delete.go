@@ -0,0 +1,76 @@
+package photo
+
+import (
+	"context"
+	"errors"
+	"picstagsbot/internal/tg/keyboard"
+	"picstagsbot/internal/tg/media"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+func (h *PhotoHandler) HandleDeleteRequest(c tele.Context) error {
+	userID := c.Sender().ID
+
+	photoID, err := photoIDFromCallback(c)
+	if err != nil {
+		logx.Warn("invalid delete callback data", "telegram_id", userID, "data", c.Data())
+		return c.RespondAlert(message.MsgPhotoNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	p, err := h.photoService.GetUserPhoto(ctx, userID, photoID)
+	if err != nil {
+		return respondPhotoError(c, err)
+	}
+
+	logx.Info("photo delete requested", "telegram_id", userID, "photo_id", photoID)
+
+	if err := c.Respond(); err != nil {
+		return err
+	}
+
+	return media.SendPhoto(c, h.archiveService, p, message.MsgDeleteConfirm, keyboard.DeleteConfirm(p.ID))
+}
+
+func (h *PhotoHandler) HandleDeleteConfirm(c tele.Context) error {
+	userID := c.Sender().ID
+
+	photoID, err := photoIDFromCallback(c)
+	if err != nil {
+		logx.Warn("invalid delete confirm callback data", "telegram_id", userID, "data", c.Data())
+		return c.RespondAlert(message.MsgPhotoNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	if err := h.photoService.DeletePhoto(ctx, userID, photoID); err != nil {
+		return respondPhotoError(c, err)
+	}
+
+	_ = c.Respond()
+	_ = c.Delete()
+
+	return message.SendWithEmoji(c, message.EmojiPhotoDeleted, message.MsgPhotoDeleted)
+}
+
+func (h *PhotoHandler) HandleDeleteCancel(c tele.Context) error {
+	_ = c.Delete()
+	return c.RespondText(message.MsgDeleteCancelled)
+}
+
+func respondPhotoError(c tele.Context, err error) error {
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return c.RespondAlert(message.MsgPhotoNotFound)
+	}
+
+	logx.Error("photo action failed", "telegram_id", c.Sender().ID, "error", err)
+	return c.RespondAlert(message.MsgPhotoActionError)
+}
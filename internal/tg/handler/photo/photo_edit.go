@@ -0,0 +1,91 @@
+package photo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"picstagsbot/internal/tg/keyboard"
+	"picstagsbot/internal/tg/media"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+func (h *PhotoHandler) HandleEditStart(c tele.Context) error {
+	userID := c.Sender().ID
+
+	photoID, err := photoIDFromCallback(c)
+	if err != nil {
+		logx.Warn("invalid edit callback data", "telegram_id", userID, "data", c.Data())
+		return c.RespondAlert(message.MsgPhotoNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	p, err := h.photoService.GetUserPhoto(ctx, userID, photoID)
+	if err != nil {
+		return respondPhotoError(c, err)
+	}
+
+	logx.Info("photo edit started", "telegram_id", userID, "photo_id", photoID)
+	h.setSession(userID, p.ID)
+
+	if err := c.Respond(); err != nil {
+		return err
+	}
+
+	current := message.PhotoCaption(p.Description, p.Tags)
+	if current == "" {
+		current = "—"
+	}
+
+	return media.SendPhoto(c, h.archiveService, p, fmt.Sprintf(message.MsgEditPrompt, current), keyboard.EditCancel(p.ID))
+}
+
+func (h *PhotoHandler) HandleEditText(c tele.Context) error {
+	userID := c.Sender().ID
+
+	photoID, ok := h.getSession(userID)
+	if !ok {
+		return nil
+	}
+
+	description := c.Text()
+
+	if len(description) > constants.MaxDescriptionLen {
+		return message.SendWithEmoji(c, message.EmojiDescriptionTooLong, message.MsgDescriptionTooLong)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	p, err := h.photoService.EditDescription(ctx, userID, photoID, description)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrValidation) {
+			return message.SendWithEmoji(c, message.EmojiInvalidDescription, message.MsgInvalidDescription)
+		}
+
+		h.clearSession(userID)
+		logx.Error("photo edit failed", "telegram_id", userID, "photo_id", photoID, "error", err)
+		return message.SendWithEmoji(c, message.EmojiPhotoSaveError, message.MsgPhotoSaveError, keyboard.MainMenu)
+	}
+
+	h.clearSession(userID)
+
+	return message.SendWithEmoji(c, message.EmojiPhotoEdited, fmt.Sprintf(message.MsgPhotoEdited, message.Hashtags(p.Tags)), keyboard.MainMenu)
+}
+
+func (h *PhotoHandler) HandleEditCancel(c tele.Context) error {
+	h.clearSession(c.Sender().ID)
+	_ = c.Delete()
+	return c.RespondText(message.MsgEditCancelled)
+}
+
+func (h *PhotoHandler) IsEditingSession(userID int64) bool {
+	_, ok := h.getSession(userID)
+	return ok
+}
@@ -5,20 +5,24 @@ import (
 	"picstagsbot/internal/service"
 	"picstagsbot/internal/tg/keyboard"
 	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
 	"picstagsbot/pkg/logx"
+	"strings"
 	"time"
 
 	tele "gopkg.in/telebot.v4"
 )
 
 type RegHandler struct {
-	regService *service.RegService
+	regService   *service.RegService
+	shareService *service.ShareService
 }
 
-func NewRegHandler(regService *service.RegService) *RegHandler {
+func NewRegHandler(regService *service.RegService, shareService *service.ShareService) *RegHandler {
 	rh := &RegHandler{}
 
 	rh.regService = regService
+	rh.shareService = shareService
 
 	return rh
 }
@@ -39,8 +43,17 @@ func (h *RegHandler) HandleRegister(c tele.Context) error {
 	}
 
 	if isExisting {
-		return message.SendWithEmoji(c, message.EmojiWelcomeExisting, message.MsgWelcomeExisting, keyboard.MainMenu)
+		err = message.SendWithEmoji(c, message.EmojiWelcomeExisting, message.MsgWelcomeExisting, keyboard.MainMenu)
+	} else {
+		err = message.SendWithEmoji(c, message.EmojiWelcomeNew, message.MsgWelcomeNew, keyboard.MainMenu)
+	}
+	if err != nil {
+		return err
+	}
+
+	if token, ok := strings.CutPrefix(c.Message().Payload, constants.SharePayloadPrefix); ok {
+		return acceptShare(c, h.shareService, token)
 	}
 
-	return message.SendWithEmoji(c, message.EmojiWelcomeNew, message.MsgWelcomeNew, keyboard.MainMenu)
+	return nil
 }
@@ -1,6 +1,7 @@
 package search
 
 import (
+	"picstagsbot/internal/domain/model"
 	"picstagsbot/internal/service"
 	"picstagsbot/pkg/constants"
 	"sync"
@@ -11,22 +12,60 @@ const (
 	albumSize = 10
 )
 
+type SearchMode string
+
+const (
+	ModeTags SearchMode = "tags"
+	ModeText SearchMode = "text"
+	ModeTag  SearchMode = "tag"
+
+	// ModeCollection browses a collection; the query is the collection id.
+	ModeCollection SearchMode = "collection"
+	// ModeShared browses a tag shared by another user; the query is the
+	// share id. Its results are read-only.
+	ModeShared SearchMode = "shared"
+)
+
 type SearchSession struct {
+	Mode         SearchMode
+	LastActivity time.Time
+}
+
+// ResultSession is a server-side cursor over the results of the last search.
+// Cursors[i] is the keyset position after which page i starts. ChatID is the
+// owner of the searched library: the user in a private chat, the group chat
+// otherwise.
+type ResultSession struct {
+	ID           int64
+	ChatID       int64
+	Mode         SearchMode
+	Query        string
+	Total        int
+	Page         int
+	Cursors      []*model.Cursor
 	LastActivity time.Time
 }
 
 type SearchHandler struct {
-	searchService *service.SearchService
-	activeSearch  map[int64]*SearchSession
-	mu            sync.RWMutex
-	stopCleanup   chan struct{}
+	searchService     *service.SearchService
+	collectionService *service.CollectionService
+	shareService      *service.ShareService
+	archiveService    *service.ArchiveService
+	activeSearch      map[int64]*SearchSession
+	results           map[int64]*ResultSession
+	mu                sync.RWMutex
+	stopCleanup       chan struct{}
 }
 
-func NewSearchHandler(searchService *service.SearchService) *SearchHandler {
+func NewSearchHandler(searchService *service.SearchService, collectionService *service.CollectionService, shareService *service.ShareService, archiveService *service.ArchiveService) *SearchHandler {
 	sh := &SearchHandler{
-		searchService: searchService,
-		activeSearch:  make(map[int64]*SearchSession),
-		stopCleanup:   make(chan struct{}),
+		searchService:     searchService,
+		collectionService: collectionService,
+		shareService:      shareService,
+		archiveService:    archiveService,
+		activeSearch:      make(map[int64]*SearchSession),
+		results:           make(map[int64]*ResultSession),
+		stopCleanup:       make(chan struct{}),
 	}
 
 	go sh.cleanupSessions()
@@ -34,27 +73,77 @@ func NewSearchHandler(searchService *service.SearchService) *SearchHandler {
 	return sh
 }
 
+// ActiveSessions returns the number of users waiting to type a search query.
+func (h *SearchHandler) ActiveSessions() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.activeSearch)
+}
+
 func (h *SearchHandler) clearSession(userID int64) {
 	h.mu.Lock()
 	delete(h.activeSearch, userID)
 	h.mu.Unlock()
 }
 
-func (h *SearchHandler) getSession(userID int64) bool {
+func (h *SearchHandler) getSession(userID int64) (SearchMode, bool) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	_, ok := h.activeSearch[userID]
-	return ok
+	session, ok := h.activeSearch[userID]
+	if !ok {
+		return "", false
+	}
+	return session.Mode, true
 }
 
-func (h *SearchHandler) setSession(userID int64) {
+func (h *SearchHandler) setSession(userID int64, mode SearchMode) {
 	h.mu.Lock()
 	h.activeSearch[userID] = &SearchSession{
+		Mode:         mode,
 		LastActivity: time.Now(),
 	}
 	h.mu.Unlock()
 }
 
+func (h *SearchHandler) getResults(userID int64) *ResultSession {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	rs, ok := h.results[userID]
+	if !ok {
+		return nil
+	}
+
+	rsCopy := *rs
+	rsCopy.Cursors = make([]*model.Cursor, len(rs.Cursors))
+	copy(rsCopy.Cursors, rs.Cursors)
+
+	return &rsCopy
+}
+
+func (h *SearchHandler) setResults(userID int64, rs *ResultSession) {
+	h.mu.Lock()
+	rs.LastActivity = time.Now()
+	h.results[userID] = rs
+	h.mu.Unlock()
+}
+
+func (h *SearchHandler) setResultsPage(userID, resultsID int64, page int, next *model.Cursor) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rs, ok := h.results[userID]
+	if !ok || rs.ID != resultsID {
+		return
+	}
+
+	rs.Page = page
+	rs.LastActivity = time.Now()
+	if next != nil && len(rs.Cursors) == page+1 {
+		rs.Cursors = append(rs.Cursors, next)
+	}
+}
+
 func (h *SearchHandler) cleanupSessions() {
 	ticker := time.NewTicker(constants.SessionCleanupInterval)
 	defer ticker.Stop()
@@ -69,6 +158,11 @@ func (h *SearchHandler) cleanupSessions() {
 					delete(h.activeSearch, userID)
 				}
 			}
+			for userID, rs := range h.results {
+				if now.Sub(rs.LastActivity) > constants.SessionTimeout {
+					delete(h.results, userID)
+				}
+			}
 			h.mu.Unlock()
 		case <-h.stopCleanup:
 			return
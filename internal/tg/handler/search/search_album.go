@@ -1,12 +1,18 @@
 package search
 
 import (
+	"fmt"
 	"picstagsbot/internal/domain/model"
+	"picstagsbot/internal/tg/keyboard"
+	"picstagsbot/internal/tg/media"
+	"picstagsbot/internal/tg/message"
 
 	tele "gopkg.in/telebot.v4"
 )
 
-func (h *SearchHandler) sendPhotosAsAlbums(c tele.Context, userID int64, photos []*model.Photo) {
+// sendPhotosAsAlbums sends photos in albums. Unless withActions is false,
+// every photo also gets its edit, delete and collection buttons.
+func (h *SearchHandler) sendPhotosAsAlbums(c tele.Context, firstNum int, photos []*model.Photo, withActions bool) {
 	for i := 0; i < len(photos); i += albumSize {
 		end := i + albumSize
 		if end > len(photos) {
@@ -19,17 +25,31 @@ func (h *SearchHandler) sendPhotosAsAlbums(c tele.Context, userID int64, photos
 		for _, p := range batch {
 			album = append(album, &tele.Photo{
 				File:    tele.File{FileID: p.TelegramID},
-				Caption: p.Description,
+				Caption: message.PhotoCaption(p.Description, p.Tags),
 			})
 		}
 
 		if err := c.SendAlbum(album); err != nil {
-			for _, p := range batch {
-				_ = c.Send(&tele.Photo{
-					File:    tele.File{FileID: p.TelegramID},
-					Caption: p.Description,
-				})
+			for j, p := range batch {
+				var opts []interface{}
+				if withActions {
+					opts = append(opts, keyboard.PhotoActions(firstNum+i+j, []int64{p.ID}))
+				}
+				_ = media.SendPhoto(c, h.archiveService, p, message.PhotoCaption(p.Description, p.Tags), opts...)
 			}
+			continue
 		}
+
+		if !withActions {
+			continue
+		}
+
+		// Albums cannot carry inline keyboards, so the actions for each
+		// photo are sent in a separate message right after the album.
+		ids := make([]int64, 0, len(batch))
+		for _, p := range batch {
+			ids = append(ids, p.ID)
+		}
+		_ = c.Send(fmt.Sprintf(message.MsgPhotoActions, firstNum+i, firstNum+end-1), keyboard.PhotoActions(firstNum+i, ids))
 	}
 }
@@ -0,0 +1,41 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// HandleCollectionShow browses the photos of a collection with the same
+// paging as search results.
+func (h *SearchHandler) HandleCollectionShow(c tele.Context) error {
+	userID := c.Sender().ID
+	query := c.Data()
+
+	logx.Info("collection browse", "telegram_id", userID, "collection_id", query)
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	result, err := h.search(ctx, userID, ModeCollection, query, nil)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return c.RespondAlert(message.MsgCollectionNotFound)
+		}
+		logx.Error("collection browse failed", "telegram_id", userID, "collection_id", query, "error", err)
+		return c.RespondAlert(message.MsgCollectionError)
+	}
+
+	if len(result.Photos) == 0 {
+		return c.RespondAlert(message.MsgCollectionEmpty)
+	}
+
+	_ = c.Respond()
+
+	return h.showResults(c, userID, ModeCollection, query, result)
+}
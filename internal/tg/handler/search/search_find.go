@@ -0,0 +1,54 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"picstagsbot/internal/service"
+	"picstagsbot/internal/tg/keyboard"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// HandleFind serves "/find <query>". In a group chat it searches the library
+// of the chat, in a private chat the user's own one. Being a command, it
+// reaches the bot even with privacy mode enabled.
+func (h *SearchHandler) HandleFind(c tele.Context) error {
+	userID := c.Sender().ID
+	chatID := c.Chat().ID
+	query := strings.TrimSpace(c.Message().Payload)
+	menu := keyboard.MainMenuFor(c.Chat())
+
+	if query == "" {
+		return message.SendWithEmoji(c, message.EmojiSearchPrompt, message.MsgFindUsage, menu)
+	}
+
+	logx.Info("find command", "telegram_id", userID, "chat_id", chatID, "query", query)
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	result, err := h.search(ctx, chatID, ModeTags, query, nil)
+
+	var qerr *service.QueryError
+	if errors.As(err, &qerr) {
+		return message.SendWithEmoji(c, message.EmojiSearchInvalidQuery, fmt.Sprintf(message.MsgSearchInvalidQuery, invalidTokens(qerr)), menu)
+	}
+
+	if errors.Is(err, apperrors.ErrNotFound) || (err == nil && len(result.Photos) == 0) {
+		logx.Info("find no results", "telegram_id", userID, "chat_id", chatID, "query", query)
+		return message.SendWithEmoji(c, message.EmojiSearchNoResults, message.MsgSearchNoResults, menu)
+	}
+
+	if err != nil {
+		logx.Error("find failed", "telegram_id", userID, "chat_id", chatID, "query", query, "error", err)
+		return message.SendWithEmoji(c, message.EmojiSearchError, message.MsgSearchError, menu)
+	}
+
+	return h.showResults(c, userID, ModeTags, query, result)
+}
@@ -2,11 +2,19 @@ package search
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/internal/service"
 	"picstagsbot/internal/tg/keyboard"
 	"picstagsbot/internal/tg/message"
 	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
 	"picstagsbot/pkg/logx"
+	"picstagsbot/pkg/validator"
+	"strconv"
+	"strings"
+	"time"
 
 	tele "gopkg.in/telebot.v4"
 )
@@ -14,48 +22,217 @@ import (
 func (h *SearchHandler) HandleSearchStart(c tele.Context) error {
 	userID := c.Sender().ID
 	logx.Info("search started", "telegram_id", userID)
-	h.setSession(userID)
-	return message.SendWithEmoji(c, message.EmojiSearchPrompt, message.MsgSearchPrompt)
+	h.setSession(userID, ModeTags)
+	return message.SendWithEmoji(c, message.EmojiSearchPrompt, message.MsgSearchPrompt, keyboard.SearchModeTagsMenu)
 }
 
-func (h *SearchHandler) HandleSearchQuery(c tele.Context, tag string) error {
+func (h *SearchHandler) HandleSearchModeTags(c tele.Context) error {
+	return h.switchMode(c, ModeTags, message.MsgSearchPrompt, keyboard.SearchModeTagsMenu)
+}
+
+func (h *SearchHandler) HandleSearchModeText(c tele.Context) error {
+	return h.switchMode(c, ModeText, message.MsgSearchTextPrompt, keyboard.SearchModeTextMenu)
+}
+
+func (h *SearchHandler) switchMode(c tele.Context, mode SearchMode, prompt string, markup *tele.ReplyMarkup) error {
+	userID := c.Sender().ID
+
+	logx.Info("search mode switched", "telegram_id", userID, "mode", mode)
+	h.setSession(userID, mode)
+
+	_ = c.Respond()
+	return c.Edit(prompt, markup)
+}
+
+func (h *SearchHandler) HandleSearchQuery(c tele.Context, query string) error {
 	userID := c.Sender().ID
 
-	if !h.getSession(userID) {
+	mode, ok := h.getSession(userID)
+	if !ok {
 		return nil
 	}
 
-	logx.Info("search query", "telegram_id", userID, "tag", tag)
+	logx.Info("search query", "telegram_id", userID, "mode", mode, "query", query)
 
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
 	defer cancel()
 
-	photos, err := h.searchService.SearchPhotosByTag(ctx, userID, tag)
+	result, err := h.search(ctx, userID, mode, query, nil)
+
+	var qerr *service.QueryError
+	if errors.As(err, &qerr) {
+		h.setSession(userID, mode)
+		return message.SendWithEmoji(c, message.EmojiSearchInvalidQuery, fmt.Sprintf(message.MsgSearchInvalidQuery, invalidTokens(qerr)))
+	}
+
+	if errors.Is(err, apperrors.ErrValidation) {
+		h.setSession(userID, mode)
+		return message.SendWithEmoji(c, message.EmojiSearchInvalidQuery, fmt.Sprintf(message.MsgSearchInvalidText, constants.MaxDescriptionLen))
+	}
 
 	h.clearSession(userID)
 
 	if err != nil {
-		logx.Error("search failed", "telegram_id", userID, "tag", tag, "error", err)
+		logx.Error("search failed", "telegram_id", userID, "query", query, "error", err)
 		return message.SendWithEmoji(c, message.EmojiSearchError, message.MsgSearchError, keyboard.MainMenu)
 	}
 
-	if len(photos) == 0 {
-		logx.Info("search no results", "telegram_id", userID, "tag", tag)
+	if len(result.Photos) == 0 {
+		logx.Info("search no results", "telegram_id", userID, "query", query)
 		return message.SendWithEmoji(c, message.EmojiSearchNoResults, message.MsgSearchNoResults, keyboard.MainMenu)
 	}
 
-	logx.Info("search completed", "telegram_id", userID, "tag", tag, "results_count", len(photos))
+	return h.showResults(c, userID, mode, query, result)
+}
+
+func (h *SearchHandler) showResults(c tele.Context, userID int64, mode SearchMode, query string, result *service.SearchResult) error {
+	logx.Info("search completed", "telegram_id", userID, "mode", mode, "query", query, "results_count", result.Total)
 
-	resultMsg := fmt.Sprintf(message.MsgSearchResults, len(photos))
+	resultMsg := fmt.Sprintf(message.MsgSearchResults, result.Total)
 	if err := message.SendWithEmoji(c, message.EmojiSearchResults, resultMsg); err != nil {
 		return err
 	}
 
-	h.sendPhotosAsAlbums(c, userID, photos)
+	rs := &ResultSession{
+		ID:      time.Now().UnixNano(),
+		ChatID:  c.Chat().ID,
+		Mode:    mode,
+		Query:   query,
+		Total:   result.Total,
+		Cursors: []*model.Cursor{nil},
+	}
+	if result.Next != nil {
+		rs.Cursors = append(rs.Cursors, result.Next)
+	}
+	h.setResults(userID, rs)
 
-	return message.SendWithEmoji(c, message.EmojiSearchCompleted, message.MsgSearchCompleted, keyboard.MainMenu)
+	return h.sendPage(c, rs, 0, result)
+}
+
+func (h *SearchHandler) HandleSearchPage(c tele.Context) error {
+	userID := c.Sender().ID
+
+	args := c.Args()
+	if len(args) != 2 {
+		return c.RespondAlert(message.MsgSearchExpired)
+	}
+
+	resultsID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return c.RespondAlert(message.MsgSearchExpired)
+	}
+
+	page, err := strconv.Atoi(args[1])
+	if err != nil {
+		return c.RespondAlert(message.MsgSearchExpired)
+	}
+
+	rs := h.getResults(userID)
+	if rs == nil || rs.ID != resultsID || page < 0 || page >= len(rs.Cursors) {
+		return c.RespondAlert(message.MsgSearchExpired)
+	}
+
+	logx.Info("search page requested", "telegram_id", userID, "query", rs.Query, "page", page)
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	result, err := h.search(ctx, rs.ChatID, rs.Mode, rs.Query, rs.Cursors[page])
+	if err != nil {
+		logx.Error("search page failed", "telegram_id", userID, "query", rs.Query, "page", page, "error", err)
+		return c.RespondAlert(message.MsgSearchError)
+	}
+
+	if len(result.Photos) == 0 {
+		return c.RespondAlert(message.MsgSearchExpired)
+	}
+
+	h.setResultsPage(userID, rs.ID, page, result.Next)
+
+	_ = c.Respond()
+	_ = c.Delete()
+
+	return h.sendPage(c, rs, page, result)
+}
+
+func (h *SearchHandler) HandleSearchPageInfo(c tele.Context) error {
+	return c.Respond()
+}
+
+func (h *SearchHandler) search(ctx context.Context, userID int64, mode SearchMode, query string, after *model.Cursor) (*service.SearchResult, error) {
+	switch mode {
+	case ModeText:
+		return h.searchService.SearchPhotosByText(ctx, userID, query, after)
+	case ModeTag:
+		return h.searchService.SearchPhotosByTag(ctx, userID, query, after)
+	case ModeCollection:
+		collectionID, err := strconv.ParseInt(query, 10, 64)
+		if err != nil {
+			return nil, apperrors.NotFoundError("collection not found")
+		}
+		return h.collectionService.ListCollectionPhotos(ctx, userID, collectionID, after)
+	case ModeShared:
+		shareID, err := strconv.ParseInt(query, 10, 64)
+		if err != nil {
+			return nil, apperrors.NotFoundError("share not found")
+		}
+		return h.shareService.SearchSharedPhotos(ctx, userID, shareID, after)
+	default:
+		return h.searchService.SearchPhotosByQuery(ctx, userID, query, after)
+	}
+}
+
+func (h *SearchHandler) sendPage(c tele.Context, rs *ResultSession, page int, result *service.SearchResult) error {
+	withActions := rs.Mode != ModeShared && rs.ChatID == c.Sender().ID
+	h.sendPhotosAsAlbums(c, page*constants.SearchPageSize+1, result.Photos, withActions)
+
+	if page == 0 && result.Next == nil {
+		return message.SendWithEmoji(c, message.EmojiSearchCompleted, message.MsgSearchCompleted, keyboard.MainMenuFor(c.Chat()))
+	}
+
+	pages := (rs.Total + constants.SearchPageSize - 1) / constants.SearchPageSize
+	if pages <= page {
+		pages = page + 1
+	}
+	if result.Next != nil && pages == page+1 {
+		pages++
+	}
+
+	return c.Send(fmt.Sprintf(message.MsgSearchPage, page+1, pages), keyboard.SearchNav(rs.ID, page, pages, result.Next != nil))
 }
 
 func (h *SearchHandler) IsSearchingSession(userID int64) bool {
-	return h.getSession(userID)
+	_, ok := h.getSession(userID)
+	return ok
+}
+
+// invalidTokens lists the rejected tokens of a query, one per line, each with
+// the reason it was rejected.
+func invalidTokens(qerr *service.QueryError) string {
+	lines := make([]string, 0, len(qerr.Tokens))
+	for _, t := range qerr.Tokens {
+		lines = append(lines, fmt.Sprintf(message.MsgQueryToken, t.Token, tokenReason(t.Err)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func tokenReason(err error) string {
+	switch {
+	case errors.Is(err, service.ErrQueryEmpty):
+		return message.MsgQueryEmpty
+	case errors.Is(err, service.ErrQueryOperator):
+		return message.MsgQueryOperator
+	case errors.Is(err, service.ErrQueryExcludedInAny):
+		return message.MsgQueryExcludedInAny
+	case errors.Is(err, validator.ErrTagEmpty):
+		return message.MsgQueryTagEmpty
+	case errors.Is(err, validator.ErrTagTooShort):
+		return fmt.Sprintf(message.MsgQueryTagTooShort, constants.MinTagLength)
+	case errors.Is(err, validator.ErrTagTooLong):
+		return fmt.Sprintf(message.MsgQueryTagTooLong, constants.MaxTagLen)
+	case errors.Is(err, validator.ErrTagInvalidChars):
+		return message.MsgQueryTagInvalidChars
+	default:
+		return message.MsgQueryTagInvalid
+	}
 }
@@ -0,0 +1,94 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"strconv"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+const startParamInline = "inline"
+
+func (h *SearchHandler) HandleInlineQuery(c tele.Context) error {
+	q := c.Query()
+	userID := q.Sender.ID
+
+	resp := &tele.QueryResponse{
+		CacheTime:  constants.InlineCacheTime,
+		IsPersonal: true,
+	}
+
+	after, err := decodeOffset(q.Offset)
+	if err != nil {
+		logx.Warn("invalid inline offset", "telegram_id", userID, "offset", q.Offset)
+		return c.Answer(resp)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	result, err := h.searchService.SearchPhotosInline(ctx, userID, q.Text, after)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrNotFound):
+			resp.Button = &tele.QueryResponseButton{Text: message.MsgInlineRegister, Start: startParamInline}
+		case errors.Is(err, apperrors.ErrValidation):
+		default:
+			logx.Error("inline search failed", "telegram_id", userID, "query", q.Text, "error", err)
+		}
+		resp.CacheTime = 0
+		return c.Answer(resp)
+	}
+
+	for _, p := range result.Photos {
+		resp.Results = append(resp.Results, &tele.PhotoResult{
+			ResultBase: tele.ResultBase{ID: strconv.FormatInt(p.ID, 10)},
+			Cache:      p.TelegramID,
+			Caption:    message.PhotoCaption(p.Description, p.Tags),
+		})
+	}
+
+	if result.Next != nil {
+		resp.NextOffset = encodeOffset(result.Next)
+	}
+
+	return c.Answer(resp)
+}
+
+// Inline offsets carry the keyset cursor of the previous page as
+// "<created_at unix nanos>.<photo id>".
+func encodeOffset(cur *model.Cursor) string {
+	return fmt.Sprintf("%d.%d", cur.CreatedAt.UnixNano(), cur.ID)
+}
+
+func decodeOffset(offset string) (*model.Cursor, error) {
+	if offset == "" {
+		return nil, nil
+	}
+
+	nanosStr, idStr, ok := strings.Cut(offset, ".")
+	if !ok {
+		return nil, fmt.Errorf("malformed offset %q", offset)
+	}
+
+	nanos, err := strconv.ParseInt(nanosStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Cursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
@@ -0,0 +1,42 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"picstagsbot/internal/tg/keyboard"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// HandleSharedShow browses the photos of a tag shared with the user. The
+// photos are sent without edit and delete buttons.
+func (h *SearchHandler) HandleSharedShow(c tele.Context) error {
+	userID := c.Sender().ID
+	query := c.Data()
+
+	logx.Info("shared tag browse", "telegram_id", userID, "share_id", query)
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	result, err := h.search(ctx, userID, ModeShared, query, nil)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return c.RespondAlert(message.MsgShareUnavailable)
+		}
+		logx.Error("shared tag browse failed", "telegram_id", userID, "share_id", query, "error", err)
+		return c.RespondAlert(message.MsgSearchError)
+	}
+
+	_ = c.Respond()
+
+	if len(result.Photos) == 0 {
+		return message.SendWithEmoji(c, message.EmojiSearchNoResults, message.MsgSearchNoResults, keyboard.MainMenu)
+	}
+
+	return h.showResults(c, userID, ModeShared, query, result)
+}
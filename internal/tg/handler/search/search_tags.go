@@ -0,0 +1,65 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"picstagsbot/internal/tg/keyboard"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	"picstagsbot/pkg/logx"
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+func (h *SearchHandler) HandleTags(c tele.Context) error {
+	userID := c.Sender().ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	stats, err := h.searchService.ListTags(ctx, userID)
+	if err != nil {
+		logx.Error("list tags failed", "telegram_id", userID, "error", err)
+		return message.SendWithEmoji(c, message.EmojiSearchError, message.MsgSearchError, keyboard.MainMenu)
+	}
+
+	if len(stats) == 0 {
+		return message.SendWithEmoji(c, message.EmojiNoTags, message.MsgNoTags, keyboard.MainMenu)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(message.MsgTagsHeader)
+
+	tags := make([]string, 0, len(stats))
+	for _, s := range stats {
+		sb.WriteString(fmt.Sprintf(message.MsgTagsLine, s.Name, s.Count, s.LastUsed.Format("02.01.2006")))
+		tags = append(tags, s.Name)
+	}
+
+	return c.Send(sb.String(), keyboard.TagCloud(tags))
+}
+
+func (h *SearchHandler) HandleTagSearch(c tele.Context) error {
+	userID := c.Sender().ID
+	tag := c.Data()
+
+	logx.Info("tag search", "telegram_id", userID, "tag", tag)
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	result, err := h.searchService.SearchPhotosByTag(ctx, userID, tag, nil)
+	if err != nil {
+		logx.Error("tag search failed", "telegram_id", userID, "tag", tag, "error", err)
+		return c.RespondAlert(message.MsgSearchError)
+	}
+
+	_ = c.Respond()
+
+	if len(result.Photos) == 0 {
+		return message.SendWithEmoji(c, message.EmojiSearchNoResults, message.MsgSearchNoResults, keyboard.MainMenu)
+	}
+
+	return h.showResults(c, userID, ModeTag, tag, result)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"picstagsbot/internal/service"
+	"picstagsbot/internal/tg/keyboard"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"strconv"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+type ShareHandler struct {
+	shareService *service.ShareService
+}
+
+func NewShareHandler(shareService *service.ShareService) *ShareHandler {
+	sh := &ShareHandler{}
+
+	sh.shareService = shareService
+
+	return sh
+}
+
+// HandleShare creates a share link for "/share <tag> [days]".
+func (h *ShareHandler) HandleShare(c tele.Context) error {
+	userID := c.Sender().ID
+
+	args := c.Args()
+	if len(args) == 0 || len(args) > 2 {
+		return message.SendWithEmoji(c, message.EmojiShareUsage, message.MsgShareUsage)
+	}
+
+	var ttl time.Duration
+	if len(args) == 2 {
+		days, err := strconv.Atoi(args[1])
+		if err != nil || days < 1 || days > constants.MaxShareDays {
+			return message.SendWithEmoji(c, message.EmojiShareInvalidDays, fmt.Sprintf(message.MsgShareInvalidDays, constants.MaxShareDays))
+		}
+		ttl = time.Duration(days) * 24 * time.Hour
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	share, err := h.shareService.CreateShare(ctx, userID, args[0], ttl)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) || errors.Is(err, apperrors.ErrValidation) {
+			return message.SendWithEmoji(c, message.EmojiShareTagNotFound, message.MsgShareTagNotFound)
+		}
+		logx.Error("share create failed", "telegram_id", userID, "error", err)
+		return message.SendWithEmoji(c, message.EmojiShareError, message.MsgShareError)
+	}
+
+	text := fmt.Sprintf(message.MsgShareCreated, share.Tag, shareLink(c, share.Token))
+	if share.ExpiresAt != nil {
+		text += fmt.Sprintf(message.MsgShareExpires, share.ExpiresAt.UTC().Format("02.01.2006 15:04 UTC"))
+	}
+
+	if err := c.Send(message.EmojiShareCreated); err != nil {
+		return err
+	}
+	return c.Send(text, keyboard.ShareRevoke(share.ID), tele.NoPreview)
+}
+
+func (h *ShareHandler) HandleShares(c tele.Context) error {
+	userID := c.Sender().ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	owned, received, err := h.shareService.ListShares(ctx, userID)
+	if err != nil {
+		logx.Error("list shares failed", "telegram_id", userID, "error", err)
+		return message.SendWithEmoji(c, message.EmojiShareError, message.MsgShareError, keyboard.MainMenu)
+	}
+
+	if len(owned) == 0 && len(received) == 0 {
+		return message.SendWithEmoji(c, message.EmojiShares, message.MsgNoShares, keyboard.MainMenu)
+	}
+
+	return message.SendWithEmoji(c, message.EmojiShares, message.MsgShares, keyboard.ShareList(owned, received))
+}
+
+func (h *ShareHandler) HandleShareRevoke(c tele.Context) error {
+	userID := c.Sender().ID
+
+	shareID, err := strconv.ParseInt(c.Data(), 10, 64)
+	if err != nil {
+		logx.Warn("invalid share revoke callback data", "telegram_id", userID, "data", c.Data())
+		return c.RespondAlert(message.MsgShareNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	if err := h.shareService.RevokeShare(ctx, userID, shareID); err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return c.RespondAlert(message.MsgShareNotFound)
+		}
+		logx.Error("share revoke failed", "telegram_id", userID, "share_id", shareID, "error", err)
+		return c.RespondAlert(message.MsgShareError)
+	}
+
+	_ = c.Delete()
+	return c.RespondText(message.MsgShareRevoked)
+}
+
+// acceptShare handles the "share_<token>" payload of /start.
+func acceptShare(c tele.Context, shareService *service.ShareService, token string) error {
+	userID := c.Sender().ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	share, err := shareService.AcceptShare(ctx, userID, token)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) || errors.Is(err, apperrors.ErrUnauthorized) {
+			return message.SendWithEmoji(c, message.EmojiShareUnavailable, message.MsgShareUnavailable)
+		}
+		logx.Error("share accept failed", "telegram_id", userID, "error", err)
+		return message.SendWithEmoji(c, message.EmojiShareError, message.MsgShareError)
+	}
+
+	return message.SendWithEmoji(c, message.EmojiShareAccepted, fmt.Sprintf(message.MsgShareAccepted, share.OwnerName, share.Tag), keyboard.ShareOpen(share))
+}
+
+func shareLink(c tele.Context, token string) string {
+	username := ""
+	if b, ok := c.Bot().(*tele.Bot); ok && b.Me != nil {
+		username = b.Me.Username
+	}
+	return fmt.Sprintf("https://t.me/%s?start=%s%s", username, constants.SharePayloadPrefix, token)
+}
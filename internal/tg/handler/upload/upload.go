@@ -12,13 +12,18 @@ type SessionState string
 const (
 	StateAwaitingPhoto       SessionState = "awaiting_photo"
 	StateAwaitingDescription SessionState = "awaiting_description"
+	StateDescribingEach      SessionState = "describing_each"
 )
 
 type UploadedPhoto struct {
-	FileID   string
-	FileSize int64
-	Width    int
-	Height   int
+	FileID      string
+	UniqueID    string
+	FileSize    int64
+	Width       int
+	Height      int
+	Caption     string
+	AlbumID     string
+	Description string
 }
 
 type UploadSession struct {
@@ -26,21 +31,34 @@ type UploadSession struct {
 	Photos          []UploadedPhoto
 	LastMediaGroup  string
 	PendingResponse bool
+	Current         int
 	LastActivity    time.Time
 }
 
+// SavedBatch remembers the photos saved by the last finished upload so they
+// can be added to a collection afterwards.
+type SavedBatch struct {
+	ID           int64
+	PhotoIDs     []int64
+	LastActivity time.Time
+}
+
 type UploadHandler struct {
-	uploadService *service.UploadService
-	sessions      map[int64]*UploadSession
-	mu            sync.RWMutex
-	stopCleanup   chan struct{}
+	uploadService     *service.UploadService
+	collectionService *service.CollectionService
+	sessions          map[int64]*UploadSession
+	batches           map[int64]*SavedBatch
+	mu                sync.RWMutex
+	stopCleanup       chan struct{}
 }
 
-func NewUploadHandler(uploadService *service.UploadService) *UploadHandler {
+func NewUploadHandler(uploadService *service.UploadService, collectionService *service.CollectionService) *UploadHandler {
 	uh := &UploadHandler{
-		uploadService: uploadService,
-		sessions:      make(map[int64]*UploadSession),
-		stopCleanup:   make(chan struct{}),
+		uploadService:     uploadService,
+		collectionService: collectionService,
+		sessions:          make(map[int64]*UploadSession),
+		batches:           make(map[int64]*SavedBatch),
+		stopCleanup:       make(chan struct{}),
 	}
 
 	go uh.cleanupSessions()
@@ -48,6 +66,13 @@ func NewUploadHandler(uploadService *service.UploadService) *UploadHandler {
 	return uh
 }
 
+// ActiveSessions returns the number of uploads in progress.
+func (h *UploadHandler) ActiveSessions() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.sessions)
+}
+
 func (h *UploadHandler) clearSession(userID int64) {
 	h.mu.Lock()
 	delete(h.sessions, userID)
@@ -67,6 +92,7 @@ func (h *UploadHandler) getSession(userID int64) *UploadSession {
 		State:           session.State,
 		LastMediaGroup:  session.LastMediaGroup,
 		PendingResponse: session.PendingResponse,
+		Current:         session.Current,
 		LastActivity:    session.LastActivity,
 		Photos:          make([]UploadedPhoto, len(session.Photos)),
 	}
@@ -124,6 +150,11 @@ func (h *UploadHandler) cleanupSessions() {
 					delete(h.sessions, userID)
 				}
 			}
+			for userID, batch := range h.batches {
+				if now.Sub(batch.LastActivity) > constants.SessionTimeout {
+					delete(h.batches, userID)
+				}
+			}
 			h.mu.Unlock()
 		case <-h.stopCleanup:
 			return
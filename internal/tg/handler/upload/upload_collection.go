@@ -0,0 +1,95 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"picstagsbot/internal/tg/keyboard"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	apperrors "picstagsbot/pkg/errors"
+	"picstagsbot/pkg/logx"
+	"strconv"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// offerCollections remembers the saved photos and asks whether to add them to
+// one of the user's collections. Nothing is asked when there are none.
+func (h *UploadHandler) offerCollections(c tele.Context, userID int64, photoIDs []int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	collections, err := h.collectionService.ListCollections(ctx, userID)
+	if err != nil {
+		logx.Warn("failed to list collections after upload", "telegram_id", userID, "error", err)
+		return nil
+	}
+
+	if len(collections) == 0 {
+		return nil
+	}
+
+	batch := &SavedBatch{
+		ID:           time.Now().UnixNano(),
+		PhotoIDs:     photoIDs,
+		LastActivity: time.Now(),
+	}
+
+	h.mu.Lock()
+	h.batches[userID] = batch
+	h.mu.Unlock()
+
+	return c.Send(message.MsgCollectionPickUpload, keyboard.CollectionChoice(keyboard.BtnCollectionAddUpload, collections, batch.ID))
+}
+
+func (h *UploadHandler) HandleAddToCollection(c tele.Context) error {
+	userID := c.Sender().ID
+
+	args := c.Args()
+	if len(args) != 2 {
+		return c.RespondAlert(message.MsgCollectionPickExpired)
+	}
+
+	collectionID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return c.RespondAlert(message.MsgCollectionNotFound)
+	}
+
+	batchID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return c.RespondAlert(message.MsgCollectionPickExpired)
+	}
+
+	h.mu.RLock()
+	batch, ok := h.batches[userID]
+	h.mu.RUnlock()
+
+	if !ok || batch.ID != batchID {
+		return c.RespondAlert(message.MsgCollectionPickExpired)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
+	defer cancel()
+
+	var added int
+	col, err := h.collectionService.GetCollection(ctx, userID, collectionID)
+	if err == nil {
+		added, err = h.collectionService.AddPhotos(ctx, userID, col.ID, batch.PhotoIDs)
+	}
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return c.RespondAlert(message.MsgCollectionNotFound)
+		}
+		logx.Error("failed to add upload to collection", "telegram_id", userID, "collection_id", collectionID, "error", err)
+		return c.RespondAlert(message.MsgCollectionError)
+	}
+
+	h.mu.Lock()
+	delete(h.batches, userID)
+	h.mu.Unlock()
+
+	_ = c.Respond()
+	return c.Edit(fmt.Sprintf(message.MsgCollectionPhotosAdded, col.Name, added))
+}
@@ -0,0 +1,164 @@
+package upload
+
+import (
+	"fmt"
+	"picstagsbot/internal/tg/keyboard"
+	"picstagsbot/internal/tg/message"
+	"picstagsbot/pkg/constants"
+	"picstagsbot/pkg/logx"
+	"strconv"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+func (h *UploadHandler) HandleDescribeEachStart(c tele.Context) error {
+	userID := c.Sender().ID
+
+	count, ok := h.startDescribeEach(userID)
+	if !ok {
+		return nil
+	}
+
+	logx.Info("upload describing each photo", "telegram_id", userID, "photos_count", count)
+
+	if err := message.SendWithEmoji(c, message.EmojiDescribeEach, message.MsgDescribeEach, &tele.ReplyMarkup{RemoveKeyboard: true}); err != nil {
+		return err
+	}
+
+	return h.sendPreview(c, userID)
+}
+
+// HandleDescribeSkip leaves the current photo without a description,
+// dropping one entered before going back to it.
+func (h *UploadHandler) HandleDescribeSkip(c tele.Context) error {
+	empty := ""
+	return h.handleDescribeNav(c, 1, &empty)
+}
+
+func (h *UploadHandler) HandleDescribeBack(c tele.Context) error {
+	return h.handleDescribeNav(c, -1, nil)
+}
+
+func (h *UploadHandler) handleDescribeNav(c tele.Context, step int, description *string) error {
+	userID := c.Sender().ID
+
+	index, err := strconv.Atoi(c.Data())
+	if err != nil || !h.moveTo(userID, index, index+step, description) {
+		return c.Respond(&tele.CallbackResponse{Text: message.MsgDescribeExpired})
+	}
+
+	_ = c.Respond()
+
+	return h.sendPreview(c, userID)
+}
+
+func (h *UploadHandler) handleDescribeEachText(c tele.Context, session *UploadSession) error {
+	userID := c.Sender().ID
+
+	description := c.Text()
+
+	if len(description) > 0 && description[0] == '/' {
+		return nil
+	}
+
+	if len(description) > constants.MaxDescriptionLen {
+		return message.SendWithEmoji(c, message.EmojiDescriptionTooLong, message.MsgDescriptionTooLong)
+	}
+
+	index := session.Current
+	if index >= len(session.Photos) {
+		return nil
+	}
+
+	photos := session.Photos
+	photos[index].Description = description
+	if err := h.uploadService.ValidateDescription(photoDescriptions(photos, "")[index]); err != nil {
+		logx.Warn("invalid upload description", "telegram_id", userID, "error", err)
+		return message.SendWithEmoji(c, message.EmojiInvalidDescription, message.MsgInvalidDescription)
+	}
+
+	if !h.moveTo(userID, index, index+1, &description) {
+		return nil
+	}
+
+	return h.sendPreview(c, userID)
+}
+
+// startDescribeEach switches a session waiting for a description to walking
+// through its photos one by one and returns the number of photos. It reports
+// false when the session is not waiting for a description.
+func (h *UploadHandler) startDescribeEach(userID int64) (int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[userID]
+	if !ok || session.State != StateAwaitingDescription {
+		return 0, false
+	}
+
+	session.State = StateDescribingEach
+	session.Current = 0
+	session.LastActivity = time.Now()
+	return len(session.Photos), true
+}
+
+// moveTo advances the session from the photo at index from to the photo at
+// index to, optionally storing a description for the photo being left. It
+// reports false when the session is no longer at index from, e.g. when a
+// button of an older preview was pressed.
+func (h *UploadHandler) moveTo(userID int64, from, to int, description *string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[userID]
+	if !ok || session.State != StateDescribingEach || session.Current != from {
+		return false
+	}
+
+	if to < 0 || from >= len(session.Photos) {
+		return false
+	}
+
+	if description != nil {
+		session.Photos[from].Description = *description
+	}
+	session.Current = to
+	session.LastActivity = time.Now()
+	return true
+}
+
+// sendPreview re-sends the photo the session is currently at, or saves the
+// whole batch once every photo has been walked through.
+func (h *UploadHandler) sendPreview(c tele.Context, userID int64) error {
+	session := h.getSession(userID)
+	if session == nil || session.State != StateDescribingEach {
+		return nil
+	}
+
+	if session.Current >= len(session.Photos) {
+		return h.finishDescribeEach(c, userID, session.Photos)
+	}
+
+	index := session.Current
+	p := session.Photos[index]
+
+	caption := fmt.Sprintf(message.MsgDescribePreview, index+1, len(session.Photos))
+	if current := photoDescriptions(session.Photos, "")[index]; current != "" {
+		caption += fmt.Sprintf(message.MsgDescribePreviewDescription, current)
+	}
+
+	return c.Send(&tele.Photo{
+		File:    tele.File{FileID: p.FileID},
+		Caption: message.TruncateCaption(caption),
+	}, keyboard.DescribeNav(index))
+}
+
+func (h *UploadHandler) finishDescribeEach(c tele.Context, userID int64, photos []UploadedPhoto) error {
+	savedIDs, duplicates := h.savePhotos(userID, photos, "")
+
+	h.clearSession(userID)
+
+	logx.Info("upload completed with individual descriptions", "telegram_id", userID, "saved_count", len(savedIDs), "duplicates", duplicates)
+	return h.sendSaved(c, userID, savedIDs, duplicates, message.EmojiPhotosSavedWithDesc, message.MsgPhotosSavedWithDesc)
+}
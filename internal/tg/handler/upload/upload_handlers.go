@@ -45,6 +45,12 @@ func (h *UploadHandler) HandleFinishUpload(c tele.Context) error {
 	logx.Info("upload awaiting description", "telegram_id", userID, "photos_count", len(session.Photos))
 	h.updateSessionState(userID, StateAwaitingDescription)
 
+	for _, p := range session.Photos {
+		if p.Caption != "" {
+			return message.SendWithEmoji(c, message.EmojiPhotoReceived, message.MsgPhotoReceivedWithCaptions, keyboard.DescriptionMenu)
+		}
+	}
+
 	return message.SendWithEmoji(c, message.EmojiPhotoReceived, message.MsgPhotoReceived, keyboard.DescriptionMenu)
 }
 
@@ -56,23 +62,22 @@ func (h *UploadHandler) HandleSkipDescription(c tele.Context) error {
 		return nil
 	}
 
-	savedCount := h.savePhotosWithoutDescription(userID, session.Photos)
+	savedIDs, duplicates := h.savePhotos(userID, session.Photos, "")
 
 	h.clearSession(userID)
 
-	if savedCount == 0 {
-		logx.Error("upload failed - no photos saved", "telegram_id", userID)
-		return message.SendWithEmoji(c, message.EmojiPhotoSaveError, message.MsgPhotoSaveError, keyboard.MainMenu)
-	}
-
-	logx.Info("upload completed without description", "telegram_id", userID, "saved_count", savedCount)
-	return message.SendWithEmoji(c, message.EmojiPhotosSaved, message.MsgPhotosSaved, keyboard.MainMenu)
+	logx.Info("upload completed without description", "telegram_id", userID, "saved_count", len(savedIDs), "duplicates", duplicates)
+	return h.sendSaved(c, userID, savedIDs, duplicates, message.EmojiPhotosSaved, message.MsgPhotosSaved)
 }
 
 func (h *UploadHandler) HandleText(c tele.Context) error {
 	userID := c.Sender().ID
 
 	session := h.getSession(userID)
+	if session != nil && session.State == StateDescribingEach {
+		return h.handleDescribeEachText(c, session)
+	}
+
 	if session == nil || session.State != StateAwaitingDescription {
 		return nil
 	}
@@ -87,17 +92,19 @@ func (h *UploadHandler) HandleText(c tele.Context) error {
 		return message.SendWithEmoji(c, message.EmojiDescriptionTooLong, message.MsgDescriptionTooLong)
 	}
 
-	savedCount := h.savePhotosWithDescription(userID, session.Photos, description)
+	for _, d := range photoDescriptions(session.Photos, description) {
+		if err := h.uploadService.ValidateDescription(d); err != nil {
+			logx.Warn("invalid upload description", "telegram_id", userID, "error", err)
+			return message.SendWithEmoji(c, message.EmojiInvalidDescription, message.MsgInvalidDescription)
+		}
+	}
 
-	h.clearSession(userID)
+	savedIDs, duplicates := h.savePhotos(userID, session.Photos, description)
 
-	if savedCount == 0 {
-		logx.Error("upload failed - no photos saved with description", "telegram_id", userID)
-		return message.SendWithEmoji(c, message.EmojiPhotoSaveError, message.MsgPhotoSaveError, keyboard.MainMenu)
-	}
+	h.clearSession(userID)
 
-	logx.Info("upload completed with description", "telegram_id", userID, "saved_count", savedCount)
-	return message.SendWithEmoji(c, message.EmojiPhotosSavedWithDesc, message.MsgPhotosSavedWithDesc, keyboard.MainMenu)
+	logx.Info("upload completed with description", "telegram_id", userID, "saved_count", len(savedIDs), "duplicates", duplicates)
+	return h.sendSaved(c, userID, savedIDs, duplicates, message.EmojiPhotosSavedWithDesc, message.MsgPhotosSavedWithDesc)
 }
 
 func (h *UploadHandler) IsUploadingSession(userID int64) bool {
@@ -29,7 +29,7 @@ func (h *UploadHandler) HandlePhoto(c tele.Context) error {
 	session := h.getSession(userID)
 	if session != nil {
 		for _, p := range session.Photos {
-			if p.FileID == photo.FileID {
+			if p.UniqueID == photo.UniqueID {
 				return message.SendWithEmoji(c, message.EmojiPhotoAlreadyExists, message.MsgPhotoAlreadyExists)
 			}
 		}
@@ -38,7 +38,10 @@ func (h *UploadHandler) HandlePhoto(c tele.Context) error {
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
 	defer cancel()
 
-	exists, err := h.uploadService.CheckPhotoExists(ctx, photo.FileID)
+	// Saving would not duplicate the photo either, but by then the user
+	// may have written a description for it, so known photos are turned
+	// away before they join the session.
+	exists, err := h.uploadService.CheckPhotoExists(ctx, userID, photo.UniqueID, photo.FileID)
 	if err != nil {
 		h.clearSession(userID)
 		logx.Error("photo check failed", "telegram_id", userID, "file_id", photo.FileID, "error", err)
@@ -49,11 +52,23 @@ func (h *UploadHandler) HandlePhoto(c tele.Context) error {
 		return message.SendWithEmoji(c, message.EmojiPhotoAlreadyExists, message.MsgPhotoAlreadyExists)
 	}
 
+	caption := c.Message().Caption
+	if caption != "" {
+		if err := h.uploadService.ValidateDescription(caption); err != nil {
+			logx.Warn("invalid photo caption", "telegram_id", userID, "file_id", photo.FileID, "error", err)
+			caption = ""
+			_ = message.SendWithEmoji(c, message.EmojiInvalidDescription, message.MsgCaptionSkipped)
+		}
+	}
+
 	newPhoto := UploadedPhoto{
 		FileID:   photo.FileID,
+		UniqueID: photo.UniqueID,
 		FileSize: int64(photo.FileSize),
 		Width:    photo.Width,
 		Height:   photo.Height,
+		Caption:  caption,
+		AlbumID:  c.Message().AlbumID,
 	}
 
 	if !h.addPhotoToSession(userID, newPhoto) {
@@ -2,56 +2,123 @@ package upload
 
 import (
 	"context"
+	"fmt"
+	"picstagsbot/internal/tg/keyboard"
+	"picstagsbot/internal/tg/message"
 	"picstagsbot/pkg/constants"
+	"picstagsbot/pkg/logx"
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
 )
 
-func (h *UploadHandler) savePhotosWithoutDescription(userID int64, photos []UploadedPhoto) int {
+// savePhotos stores the session's photos and returns the ids of the photos
+// that were not uploaded before together with the number of photos that
+// were. Those keep their stored description.
+func (h *UploadHandler) savePhotos(userID int64, photos []UploadedPhoto, description string) ([]int64, int) {
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
 	defer cancel()
 
-	savedCount := 0
-	for _, p := range photos {
-		isExisting, err := h.uploadService.UploadPhoto(
+	descriptions := photoDescriptions(photos, description)
+
+	var savedIDs []int64
+	var duplicates int
+	for i, p := range photos {
+		if descriptions[i] == "" {
+			photo, isExisting, err := h.uploadService.UploadPhoto(
+				ctx,
+				userID,
+				p.FileID,
+				p.UniqueID,
+				p.FileSize,
+				p.Width,
+				p.Height,
+			)
+
+			if err != nil {
+				continue
+			}
+
+			if isExisting {
+				duplicates++
+			} else {
+				savedIDs = append(savedIDs, photo.ID)
+			}
+			continue
+		}
+
+		photo, isExisting, err := h.uploadService.SavePhotoWithDescription(
 			ctx,
 			userID,
 			p.FileID,
+			p.UniqueID,
 			p.FileSize,
 			p.Width,
 			p.Height,
+			descriptions[i],
 		)
 
 		if err != nil {
 			continue
 		}
 
-		if !isExisting {
-			savedCount++
+		if isExisting {
+			duplicates++
+		} else {
+			savedIDs = append(savedIDs, photo.ID)
 		}
 	}
-	return savedCount
+	return savedIDs, duplicates
 }
 
-func (h *UploadHandler) savePhotosWithDescription(userID int64, photos []UploadedPhoto, description string) int {
-	ctx, cancel := context.WithTimeout(context.Background(), constants.DBQueryTimeout)
-	defer cancel()
+// sendSaved reports the outcome of savePhotos with text and offers to add
+// the saved photos to a collection.
+func (h *UploadHandler) sendSaved(c tele.Context, userID int64, savedIDs []int64, duplicates int, emoji, text string) error {
+	if len(savedIDs) == 0 {
+		if duplicates > 0 {
+			return message.SendWithEmoji(c, message.EmojiPhotoAlreadyExists, message.MsgPhotosAlreadyExist, keyboard.MainMenu)
+		}
+		logx.Error("upload failed - no photos saved", "telegram_id", userID)
+		return message.SendWithEmoji(c, message.EmojiPhotoSaveError, message.MsgPhotoSaveError, keyboard.MainMenu)
+	}
+
+	if duplicates > 0 {
+		text += fmt.Sprintf(message.MsgPhotosDuplicates, duplicates)
+	}
+	if err := message.SendWithEmoji(c, emoji, text, keyboard.MainMenu); err != nil {
+		return err
+	}
 
-	savedCount := 0
+	return h.offerCollections(c, userID, savedIDs)
+}
+
+// photoDescriptions merges each photo's own caption and individual
+// description with the batch description. Telegram attaches an album caption
+// to one item of the media group only, so when a single item of an album has
+// a caption it is applied to every photo of that album.
+func photoDescriptions(photos []UploadedPhoto, description string) []string {
+	albumCaptions := make(map[string][]string)
 	for _, p := range photos {
-		_, err := h.uploadService.SavePhotoWithDescription(
-			ctx,
-			userID,
-			p.FileID,
-			p.FileSize,
-			p.Width,
-			p.Height,
-			description,
-		)
+		if p.AlbumID != "" && p.Caption != "" {
+			albumCaptions[p.AlbumID] = append(albumCaptions[p.AlbumID], p.Caption)
+		}
+	}
 
-		if err != nil {
-			continue
+	descriptions := make([]string, len(photos))
+	for i, p := range photos {
+		caption := p.Caption
+		if caption == "" && len(albumCaptions[p.AlbumID]) == 1 {
+			caption = albumCaptions[p.AlbumID][0]
 		}
 
-		savedCount++
+		parts := make([]string, 0, 3)
+		for _, part := range []string{caption, p.Description, description} {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		descriptions[i] = strings.Join(parts, "\n")
 	}
-	return savedCount
+
+	return descriptions
 }
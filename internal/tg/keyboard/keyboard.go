@@ -1,6 +1,13 @@
 package keyboard
 
-import tele "gopkg.in/telebot.v4"
+import (
+	"fmt"
+	"picstagsbot/internal/domain/model"
+	"strconv"
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
+)
 
 var MainMenu = &tele.ReplyMarkup{
 	ResizeKeyboard: true,
@@ -14,25 +21,322 @@ var FinishUploadMenu = &tele.ReplyMarkup{
 	ResizeKeyboard: true,
 }
 
+var SearchModeTagsMenu = &tele.ReplyMarkup{}
+
+var SearchModeTextMenu = &tele.ReplyMarkup{}
+
+var inlineMenu = &tele.ReplyMarkup{}
+
 var (
 	BtnUploadPhoto     = MainMenu.Text("Загрузить фото")
 	BtnSearchPhoto     = MainMenu.Text("Найти фотографию")
+	BtnMyTags          = MainMenu.Text("Мои тэги")
+	BtnMyCollections   = MainMenu.Text("Мои альбомы")
 	BtnAddDescription  = DescriptionMenu.Text("Добавить описание")
 	BtnSkipDescription = DescriptionMenu.Text("Продолжить")
+	BtnDescribeEach    = DescriptionMenu.Text("Описать каждое отдельно")
 	BtnFinishUpload    = FinishUploadMenu.Text("Завершить")
 )
 
+var (
+	BtnSearchByTags = inlineMenu.Data("🏷 Искать по тэгам", "search_mode_tags")
+	BtnSearchByText = inlineMenu.Data("📝 Искать по описанию", "search_mode_text")
+	BtnSearchPrev   = inlineMenu.Data("◀️ Назад", "search_prev")
+	BtnSearchNext   = inlineMenu.Data("Далее ▶️", "search_next")
+	BtnSearchPage   = inlineMenu.Data("", "search_page")
+	BtnTagSearch    = inlineMenu.Data("", "tag_search")
+)
+
+// Telegram limits callback data to 64 bytes, including the "\f<unique>|"
+// prefix telebot adds to it.
+const maxCallbackData = 64
+
+var (
+	BtnDeletePhoto   = inlineMenu.Data("🗑 Удалить", "photo_delete")
+	BtnConfirmDelete = inlineMenu.Data("Да, удалить", "photo_delete_yes")
+	BtnCancelDelete  = inlineMenu.Data("Отмена", "photo_delete_no")
+	BtnEditPhoto     = inlineMenu.Data("✏️ Изменить", "photo_edit")
+	BtnCancelEdit    = inlineMenu.Data("Отмена", "photo_edit_no")
+)
+
+var (
+	BtnCollectionOpen       = inlineMenu.Data("", "collection_open")
+	BtnCollectionNew        = inlineMenu.Data("➕ Новый альбом", "collection_new")
+	BtnCollectionShow       = inlineMenu.Data("📷 Показать фото", "collection_show")
+	BtnCollectionRename     = inlineMenu.Data("✏️ Переименовать", "collection_rename")
+	BtnCollectionDelete     = inlineMenu.Data("🗑 Удалить", "collection_delete")
+	BtnCollectionDeleteYes  = inlineMenu.Data("Да, удалить", "collection_delete_yes")
+	BtnCollectionDeleteNo   = inlineMenu.Data("Отмена", "collection_delete_no")
+	BtnCollectionPickPhoto  = inlineMenu.Data("📁 В альбом", "collection_pick")
+	BtnCollectionAddPhoto   = inlineMenu.Data("", "collection_add")
+	BtnCollectionAddUpload  = inlineMenu.Data("", "collection_add_upload")
+	BtnCollectionCancelPick = inlineMenu.Data("Отмена", "collection_pick_no")
+)
+
+var (
+	BtnShareRevoke = inlineMenu.Data("", "share_revoke")
+	BtnShareOpen   = inlineMenu.Data("", "share_open")
+)
+
+var (
+	BtnImportApply  = inlineMenu.Data("✅ Применить", "import_apply")
+	BtnImportCancel = inlineMenu.Data("Отмена", "import_no")
+)
+
+var (
+	BtnDescribeBack = inlineMenu.Data("◀️ Назад", "upload_each_back")
+	BtnDescribeSkip = inlineMenu.Data("Пропустить ▶️", "upload_each_skip")
+)
+
 func init() {
 	MainMenu.Reply(
 		MainMenu.Row(BtnUploadPhoto),
 		MainMenu.Row(BtnSearchPhoto),
+		MainMenu.Row(BtnMyTags, BtnMyCollections),
 	)
 
 	DescriptionMenu.Reply(
 		DescriptionMenu.Row(BtnAddDescription, BtnSkipDescription),
+		DescriptionMenu.Row(BtnDescribeEach),
 	)
 
 	FinishUploadMenu.Reply(
 		FinishUploadMenu.Row(BtnFinishUpload),
 	)
+
+	SearchModeTagsMenu.Inline(
+		SearchModeTagsMenu.Row(BtnSearchByText),
+	)
+
+	SearchModeTextMenu.Inline(
+		SearchModeTextMenu.Row(BtnSearchByTags),
+	)
+}
+
+// MainMenuFor returns the main menu for private chats. Group chats get no
+// reply keyboard so that it does not pop up for every member.
+func MainMenuFor(chat *tele.Chat) *tele.ReplyMarkup {
+	if chat == nil || chat.Type == tele.ChatPrivate {
+		return MainMenu
+	}
+	return nil
+}
+
+// PhotoActions builds an inline keyboard with one row of actions per photo.
+// Photos are numbered starting from firstNum so the buttons match the order
+// in which the photos were sent.
+func PhotoActions(firstNum int, photoIDs []int64) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	rows := make([]tele.Row, 0, len(photoIDs))
+	for i, id := range photoIDs {
+		num := firstNum + i
+		rows = append(rows, markup.Row(
+			withData(BtnEditPhoto, fmt.Sprintf("%s №%d", BtnEditPhoto.Text, num), id),
+			withData(BtnDeletePhoto, fmt.Sprintf("%s №%d", BtnDeletePhoto.Text, num), id),
+			withData(BtnCollectionPickPhoto, fmt.Sprintf("%s №%d", BtnCollectionPickPhoto.Text, num), id),
+		))
+	}
+
+	markup.Inline(rows...)
+	return markup
+}
+
+func DeleteConfirm(photoID int64) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	markup.Inline(
+		markup.Row(
+			withData(BtnConfirmDelete, BtnConfirmDelete.Text, photoID),
+			withData(BtnCancelDelete, BtnCancelDelete.Text, photoID),
+		),
+	)
+
+	return markup
+}
+
+// SearchNav builds the pagination keyboard for a search result. Every button
+// carries resultsID so that keyboards of older searches can be told apart.
+func SearchNav(resultsID int64, page, pages int, hasNext bool) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	id := strconv.FormatInt(resultsID, 10)
+
+	var row tele.Row
+	if page > 0 {
+		row = append(row, withArgs(BtnSearchPrev, BtnSearchPrev.Text, id, strconv.Itoa(page-1)))
+	}
+	row = append(row, withArgs(BtnSearchPage, fmt.Sprintf("%d / %d", page+1, pages), id))
+	if hasNext {
+		row = append(row, withArgs(BtnSearchNext, BtnSearchNext.Text, id, strconv.Itoa(page+1)))
+	}
+
+	markup.Inline(row)
+	return markup
+}
+
+// TagCloud renders tags as buttons that search for the tag. Tags too long to
+// fit into callback data are left out of the keyboard.
+func TagCloud(tags []string) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	btns := make([]tele.Btn, 0, len(tags))
+	for _, tag := range tags {
+		if len(BtnTagSearch.Unique)+len(tag)+2 > maxCallbackData {
+			continue
+		}
+		btns = append(btns, withArgs(BtnTagSearch, "#"+tag, tag))
+	}
+
+	markup.Inline(markup.Split(3, btns)...)
+	return markup
+}
+
+func withData(btn tele.Btn, text string, id int64) tele.Btn {
+	return withArgs(btn, text, strconv.FormatInt(id, 10))
+}
+
+func withArgs(btn tele.Btn, text string, args ...string) tele.Btn {
+	btn.Text = text
+	btn.Data = strings.Join(args, "|")
+	return btn
+}
+
+func EditCancel(photoID int64) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	markup.Inline(
+		markup.Row(withData(BtnCancelEdit, BtnCancelEdit.Text, photoID)),
+	)
+
+	return markup
+}
+
+// DescribeNav builds the keyboard under the preview of the index-th photo
+// while photos of an upload are described one by one. Every button carries
+// the index so that presses on older previews can be ignored.
+func DescribeNav(index int) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	idx := strconv.Itoa(index)
+
+	var row tele.Row
+	if index > 0 {
+		row = append(row, withArgs(BtnDescribeBack, BtnDescribeBack.Text, idx))
+	}
+	row = append(row, withArgs(BtnDescribeSkip, BtnDescribeSkip.Text, idx))
+
+	markup.Inline(row)
+	return markup
+}
+
+// CollectionList renders the user's collections as buttons that open them,
+// followed by a button that creates a new one.
+func CollectionList(collections []*model.Collection) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	rows := make([]tele.Row, 0, len(collections)+1)
+	for _, col := range collections {
+		rows = append(rows, markup.Row(
+			withData(BtnCollectionOpen, fmt.Sprintf("📁 %s (%d)", col.Name, col.PhotoCount), col.ID),
+		))
+	}
+	rows = append(rows, markup.Row(BtnCollectionNew))
+
+	markup.Inline(rows...)
+	return markup
+}
+
+func CollectionActions(collectionID int64) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	markup.Inline(
+		markup.Row(withData(BtnCollectionShow, BtnCollectionShow.Text, collectionID)),
+		markup.Row(
+			withData(BtnCollectionRename, BtnCollectionRename.Text, collectionID),
+			withData(BtnCollectionDelete, BtnCollectionDelete.Text, collectionID),
+		),
+	)
+
+	return markup
+}
+
+func CollectionDeleteConfirm(collectionID int64) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	markup.Inline(
+		markup.Row(
+			withData(BtnCollectionDeleteYes, BtnCollectionDeleteYes.Text, collectionID),
+			withData(BtnCollectionDeleteNo, BtnCollectionDeleteNo.Text, collectionID),
+		),
+	)
+
+	return markup
+}
+
+// CollectionChoice lets the user pick a collection for the photos referenced
+// by ref. Each button carries "<collection id>|<ref>".
+func CollectionChoice(btn tele.Btn, collections []*model.Collection, ref int64) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	refArg := strconv.FormatInt(ref, 10)
+
+	rows := make([]tele.Row, 0, len(collections)+1)
+	for _, col := range collections {
+		rows = append(rows, markup.Row(
+			withArgs(btn, "📁 "+col.Name, strconv.FormatInt(col.ID, 10), refArg),
+		))
+	}
+	rows = append(rows, markup.Row(BtnCollectionCancelPick))
+
+	markup.Inline(rows...)
+	return markup
+}
+
+// ShareList renders the links the user has created as buttons that revoke
+// them, followed by the tags shared with the user as buttons that open them.
+func ShareList(owned, received []*model.Share) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	rows := make([]tele.Row, 0, len(owned)+len(received))
+	for _, s := range owned {
+		rows = append(rows, markup.Row(withData(BtnShareRevoke, "❌ Отозвать #"+s.Tag, s.ID)))
+	}
+	for _, s := range received {
+		rows = append(rows, markup.Row(withData(BtnShareOpen, fmt.Sprintf("📷 #%s от @%s", s.Tag, s.OwnerName), s.ID)))
+	}
+
+	markup.Inline(rows...)
+	return markup
+}
+
+func ShareOpen(share *model.Share) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	markup.Inline(
+		markup.Row(withData(BtnShareOpen, "📷 Показать #"+share.Tag, share.ID)),
+	)
+
+	return markup
+}
+
+func ShareRevoke(shareID int64) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	markup.Inline(
+		markup.Row(withData(BtnShareRevoke, "❌ Отозвать ссылку", shareID)),
+	)
+
+	return markup
+}
+
+// ImportConfirm asks to apply the import checked under importID.
+func ImportConfirm(importID int64) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	markup.Inline(
+		markup.Row(
+			withData(BtnImportApply, BtnImportApply.Text, importID),
+			withData(BtnImportCancel, BtnImportCancel.Text, importID),
+		),
+	)
+
+	return markup
 }
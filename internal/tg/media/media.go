@@ -0,0 +1,54 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"picstagsbot/internal/domain/model"
+	"picstagsbot/internal/service"
+	"picstagsbot/pkg/constants"
+	"picstagsbot/pkg/logx"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// SendPhoto sends a stored photo by its file_id. When Telegram rejects the
+// file_id the photo is uploaded again from blob storage and the new file_id
+// is saved for the next time.
+func SendPhoto(c tele.Context, archive *service.ArchiveService, p *model.Photo, caption string, opts ...interface{}) error {
+	err := c.Send(&tele.Photo{File: tele.File{FileID: p.TelegramID}, Caption: caption}, opts...)
+	if err == nil || !IsStaleFileID(err) || archive == nil || p.StorageKey == "" {
+		return err
+	}
+
+	logx.Warn("photo file_id rejected, re-uploading from storage", "photo_id", p.ID, "error", err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.StorageTimeout)
+	defer cancel()
+
+	data, loadErr := archive.Load(ctx, p)
+	if loadErr != nil {
+		return err
+	}
+
+	photo := &tele.Photo{File: tele.FromReader(bytes.NewReader(data)), Caption: caption}
+	msg, err := c.Bot().Send(c.Recipient(), photo, opts...)
+	if err != nil {
+		logx.Error("photo re-upload failed", "photo_id", p.ID, "error", err)
+		return err
+	}
+
+	if msg.Photo != nil {
+		_ = archive.ReplaceFileID(ctx, p, msg.Photo.FileID)
+	}
+	return nil
+}
+
+// IsStaleFileID reports whether Telegram refused a file_id it does not know.
+func IsStaleFileID(err error) bool {
+	return errors.Is(err, tele.ErrWrongFileID) ||
+		errors.Is(err, tele.ErrWrongFileIDCharacter) ||
+		errors.Is(err, tele.ErrWrongFileIDLength) ||
+		errors.Is(err, tele.ErrWrongFileIDPadding) ||
+		errors.Is(err, tele.ErrWrongFileIDSymbol)
+}
@@ -0,0 +1,50 @@
+package message
+
+import (
+	"picstagsbot/pkg/constants"
+	"picstagsbot/pkg/validator"
+	"strings"
+)
+
+// PhotoCaption renders the description followed by the photo's tags as
+// hashtags. Tags already present as words of the description are not
+// repeated, so descriptions parsed word by word stay as they were typed.
+func PhotoCaption(description string, tags []string) string {
+	present := make(map[string]struct{})
+	for _, word := range validator.NormalizeTags(validator.Tokenize(description)) {
+		present[word] = struct{}{}
+	}
+
+	var hashtags []string
+	for _, tag := range tags {
+		if _, ok := present[tag]; !ok {
+			hashtags = append(hashtags, "#"+tag)
+		}
+	}
+
+	caption := description
+	if len(hashtags) > 0 {
+		if caption != "" {
+			caption += "\n\n"
+		}
+		caption += strings.Join(hashtags, " ")
+	}
+
+	return TruncateCaption(caption)
+}
+
+// TruncateCaption cuts caption to the length Telegram accepts for media.
+func TruncateCaption(caption string) string {
+	if runes := []rune(caption); len(runes) > constants.MaxCaptionLen {
+		return string(runes[:constants.MaxCaptionLen-1]) + "…"
+	}
+	return caption
+}
+
+func Hashtags(tags []string) string {
+	hashtags := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		hashtags = append(hashtags, "#"+tag)
+	}
+	return strings.Join(hashtags, " ")
+}
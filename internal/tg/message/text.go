@@ -20,6 +20,10 @@ const (
 	EmojiPhotoReceived = "😊"
 	MsgPhotoReceived   = "Фото получено! Хотите добавить описание?"
 
+	MsgPhotoReceivedWithCaptions = "Фото получено! Подписи к фото сохранятся.\nХотите добавить общее описание ко всем фото?"
+
+	MsgCaptionSkipped = "Подпись к фото не удалось разобрать, она будет пропущена. Тэги могут содержать только буквы, цифры, _ и -"
+
 	EmojiPhotoAdded = "👍"
 	MsgPhotoAdded   = "Фото добавлены!\nОтправьте ещё или нажмите Завершить"
 
@@ -30,14 +34,17 @@ const (
 	MsgEnterDescription   = "Введите описание для фото:"
 
 	EmojiPhotosSaved = "🙌"
-	MsgPhotosSaved   = "Все фото сохранены!"
+	MsgPhotosSaved   = "Фото сохранены!"
 
 	EmojiPhotosSavedWithDesc = "😌"
-	MsgPhotosSavedWithDesc   = "Все фото с описанием сохранены!"
+	MsgPhotosSavedWithDesc   = "Фото с описанием сохранены!"
 
 	EmojiPhotoAlreadyExists = "😐"
 	MsgPhotoAlreadyExists   = "Это фото уже было загружено ранее"
 
+	MsgPhotosAlreadyExist = "Все эти фото уже были загружены ранее"
+	MsgPhotosDuplicates   = "\nУже загружены ранее и пропущены: %d"
+
 	EmojiPhotoSaveError = "😥"
 	MsgPhotoSaveError   = "Ошибка при сохранении фото"
 
@@ -51,13 +58,41 @@ const (
 	MsgPhotoLimitReached   = "Достигнут лимит фотографий (%d). Завершите загрузку."
 )
 
+// upload_each.go
+const (
+	EmojiDescribeEach = "✍️"
+	MsgDescribeEach   = "Отправьте описание для каждого фото по очереди.\nКнопка «Пропустить» оставит фото без описания, «Назад» вернёт к предыдущему"
+
+	MsgDescribePreview            = "Фото %d из %d"
+	MsgDescribePreviewDescription = "\n\nОписание: %s"
+
+	MsgDescribeExpired = "Это фото уже пройдено"
+)
+
 // search.go
 const (
 	EmojiSearchPrompt = "🤔"
-	MsgSearchPrompt   = "Введите тэг для поиска фотографий:"
+	MsgSearchPrompt   = "Введите тэги для поиска фотографий:\n• море закат — оба тэга\n• кот | собака — любой из тэгов\n• -люди — без этого тэга"
+
+	MsgSearchTextPrompt = "Введите слова или фразу из описания фотографий:"
+
+	EmojiSearchInvalidQuery = "🤨"
+	MsgSearchInvalidQuery   = "Не удалось разобрать запрос:\n%s\nТэги состоят из букв, цифр, _ и -, «|» ставится между тэгами, «-» перед тэгом исключает его. Попробуйте ещё раз:"
+
+	MsgSearchInvalidText = "Запрос не должен быть пустым или длиннее %d символов. Попробуйте ещё раз:"
+
+	MsgQueryToken           = "• «%s» — %s"
+	MsgQueryEmpty           = "запрос пуст"
+	MsgQueryOperator        = "«|» должен стоять между двумя тэгами"
+	MsgQueryExcludedInAny   = "исключённый тэг нельзя объединять через «|»"
+	MsgQueryTagEmpty        = "пустой тэг"
+	MsgQueryTagTooShort     = "тэг слишком короткий, минимум символов: %d"
+	MsgQueryTagTooLong      = "тэг слишком длинный, максимум символов: %d"
+	MsgQueryTagInvalidChars = "недопустимые символы"
+	MsgQueryTagInvalid      = "некорректный тэг"
 
 	EmojiSearchNoResults = "🙁"
-	MsgSearchNoResults   = "Фотографии с таким тэгом не найдены"
+	MsgSearchNoResults   = "Фотографии по такому запросу не найдены"
 
 	EmojiSearchError = "😣"
 	MsgSearchError   = "Ошибка при поиске фотографий"
@@ -67,12 +102,195 @@ const (
 
 	EmojiSearchResults = "☺️"
 	MsgSearchResults   = "Найдено фотографий: %d"
+
+	MsgSearchPage = "Страница %d из %d"
+
+	MsgSearchExpired = "Результаты поиска устарели, выполните поиск заново"
+
+	MsgInlineRegister = "Начните работу с ботом"
+)
+
+// search_tags.go
+const (
+	MsgTagsHeader = "🏷 Ваши тэги (нажмите, чтобы найти фото):\n\n"
+	MsgTagsLine   = "#%s — %d, последнее %s\n"
+
+	EmojiNoTags = "🤷"
+	MsgNoTags   = "У вас пока нет тэгов. Добавьте описание при загрузке фото"
+)
+
+// photo.go
+const (
+	MsgPhotoActions = "Действия с фото №%d–%d:"
+
+	MsgDeleteConfirm = "Удалить это фото? Отменить удаление будет нельзя."
+
+	EmojiPhotoDeleted = "🗑"
+	MsgPhotoDeleted   = "Фото удалено"
+
+	MsgDeleteCancelled = "Удаление отменено"
+
+	MsgPhotoNotFound = "Фото не найдено"
+
+	MsgEditPrompt = "Текущее описание: %s\n\nОтправьте новое описание — из него будут взяты тэги:"
+
+	EmojiPhotoEdited = "✅"
+	MsgPhotoEdited   = "Описание обновлено!\nТеги: %s"
+
+	MsgEditCancelled = "Редактирование отменено"
+
+	MsgPhotoActionError = "Ошибка при обработке фото"
+)
+
+// collection.go
+const (
+	EmojiCollections = "📁"
+	MsgCollections   = "Ваши альбомы:"
+	MsgNoCollections = "У вас пока нет альбомов. Создайте первый:"
+
+	MsgCollectionInfo = "📁 «%s» — фото: %d"
+
+	EmojiCollectionName = "✍️"
+	MsgCollectionName   = "Введите название альбома:"
+	MsgCollectionRename = "Введите новое название для альбома «%s»:"
+
+	EmojiCollectionCreated = "📁"
+	MsgCollectionCreated   = "Альбом «%s» создан"
+
+	EmojiCollectionRenamed = "✅"
+	MsgCollectionRenamed   = "Альбом переименован в «%s»"
+
+	EmojiCollectionInvalidName = "🤨"
+	MsgCollectionInvalidName   = "Название должно быть одной строкой не длиннее 64 символов. Попробуйте ещё раз:"
+
+	EmojiCollectionExists = "😐"
+	MsgCollectionExists   = "Альбом с таким названием уже есть. Введите другое название:"
+
+	MsgCollectionDeleteConfirm = "Удалить альбом «%s»? Сами фото останутся в библиотеке."
+	MsgCollectionDeleted       = "Альбом удалён"
+	MsgCollectionDeleteCancel  = "Удаление отменено"
+
+	MsgCollectionNotFound = "Альбом не найден"
+	MsgCollectionEmpty    = "В этом альбоме пока нет фото"
+	EmojiCollectionError  = "😣"
+	MsgCollectionError    = "Ошибка при работе с альбомом"
+
+	MsgCollectionPick        = "Выберите альбом для фото:"
+	MsgCollectionPickUpload  = "Добавить загруженные фото в альбом?"
+	MsgCollectionPickNone    = "Сначала создайте альбом в меню «Мои альбомы»"
+	MsgCollectionPickCancel  = "Фото не добавлены в альбом"
+	MsgCollectionPickExpired = "Загрузка уже неактуальна"
+
+	MsgCollectionPhotosAdded = "Добавлено в альбом «%s»: %d"
+)
+
+// share.go
+const (
+	EmojiShareUsage = "🔗"
+	MsgShareUsage   = "Чтобы поделиться тэгом, отправьте /share <тэг> [дней]\nНапример: /share море 7 — ссылка будет действовать 7 дней"
+
+	EmojiShareCreated = "🔗"
+	MsgShareCreated   = "Ссылка на фото с тэгом #%s:\n%s\n\nОткрыть её сможет только один человек — тот, кто перейдёт первым."
+	MsgShareExpires   = "\nСсылка действует до %s."
+
+	EmojiShareError = "😣"
+	MsgShareError   = "Ошибка при работе со ссылкой"
+
+	EmojiShareTagNotFound = "🤷"
+	MsgShareTagNotFound   = "У вас нет фото с таким тэгом"
+
+	EmojiShareInvalidDays = "🤨"
+	MsgShareInvalidDays   = "Срок действия — число дней от 1 до %d"
+
+	EmojiShareAccepted = "🔗"
+	MsgShareAccepted   = "@%s открыл(а) вам доступ к фото с тэгом #%s"
+
+	EmojiShareUnavailable = "😕"
+	MsgShareUnavailable   = "Ссылка недействительна: она отозвана, истекла или уже использована"
+
+	EmojiShares = "🔗"
+	MsgShares   = "Ваши ссылки и тэги, которыми поделились с вами:"
+	MsgNoShares = "Ссылок пока нет. Поделиться тэгом: /share <тэг>"
+
+	MsgShareRevoked  = "Ссылка отозвана"
+	MsgShareNotFound = "Ссылка не найдена"
+)
+
+// export.go
+const (
+	EmojiExportStarted = "📦"
+	MsgExportStarted   = "Собираю архив со всеми вашими фото. Это может занять несколько минут — пришлю его, когда будет готов."
+
+	EmojiExportRunning = "⏳"
+	MsgExportRunning   = "Архив уже собирается, дождитесь его"
+
+	EmojiExportEmpty = "🤷"
+	MsgExportEmpty   = "В вашей библиотеке пока нет фото"
+
+	EmojiExportDone = "✅"
+	MsgExportDone   = "Готово! Фото в архиве: %d, частей: %d"
+	MsgExportFailed = "\nНе удалось скачать фото: %d"
+
+	EmojiExportError = "😣"
+	MsgExportError   = "Не удалось собрать архив, попробуйте позже"
+
+	MsgExportPart = "Часть %d, фото: %d"
+)
+
+// import.go
+const (
+	EmojiImportUnsupported = "📄"
+	MsgImportUnsupported   = "Пришлите файл .csv или .json со столбцами file или file_unique_id, description и tags — например, manifest из /export"
+
+	EmojiImportTooLarge = "📄"
+	MsgImportTooLarge   = "Файл слишком большой. Максимальный размер: %d MB"
+
+	EmojiImportInvalid = "🤨"
+	MsgImportInvalid   = "Не удалось прочитать файл. Проверьте формат: нужен CSV с заголовком или JSON-массив"
+
+	EmojiImportError = "😣"
+	MsgImportError   = "Ошибка при импорте тэгов"
+
+	EmojiImportPlan = "📋"
+	MsgImportPlan   = "Проверка файла (ничего ещё не изменено):\nСтрок: %d\nНайдено фото: %d\nНе найдено: %d\nС ошибками: %d"
+	MsgImportRows   = "\n%s: %s"
+
+	MsgImportUnknownRows = "Не найдены строки"
+	MsgImportInvalidRows = "Ошибки в строках"
+
+	MsgImportNothing = "\n\nПрименять нечего."
+	MsgImportConfirm = "\n\nПрименить изменения? Описания и тэги найденных фото будут заменены."
+
+	MsgImportApplied  = "Готово! Обновлено фото: %d"
+	MsgImportExpired  = "Проверка устарела, пришлите файл ещё раз"
+	MsgImportCanceled = "Импорт отменён"
+)
+
+// group.go
+const (
+	MsgFindUsage = "Укажите тэги после команды, например: /find море закат"
+
+	MsgGroupSaveUsage  = "Ответьте командой /save на сообщение с фото, чтобы добавить его в библиотеку чата"
+	MsgGroupSaveNoTags = "Не нашлось тэгов: добавьте #тэги в подпись к фото или укажите их после /save"
+	MsgGroupSaved      = "Фото добавлено в библиотеку чата: "
+
+	MsgGroupAlreadySaved = "Это фото уже есть в библиотеке чата: "
+
+	MsgGroupHelp = `❓ Библиотека чата
+
+• Фото с #тэгами в подписи сохраняются в общую библиотеку чата (если у бота выключен режим приватности)
+• /save — ответом на фото: сохранить его с #тэгами из подписи
+• /save кот рыжий — ответом на фото: сохранить с этими тэгами
+• /find кот | собака — найти фото в библиотеке чата`
 )
 
 // common
 const (
 	EmojiUseButtons = "👇"
 	MsgUseButtons   = "Пожалуйста, используйте кнопки в меню для работы с ботом"
+
+	EmojiInvalidDescription = "🤨"
+	MsgInvalidDescription   = "Не удалось разобрать описание: тэги могут содержать только буквы, цифры, _ и - (не длиннее 100 символов, не больше 50 тэгов)\nПопробуйте ещё раз"
 )
 
 // info.go
@@ -97,17 +315,39 @@ const (
 
 📸 Загрузка фото:
 1. Нажмите "Загрузить фото"
-2. Отправьте одно или несколько фото (можно альбомом)
+2. Отправьте одно или несколько фото (можно альбомом), подпись к фото тоже станет описанием
 3. Нажмите "Завершить" когда все фото отправлены
-4. Добавьте описание (слова станут тегами) или пропустите
+4. Добавьте описание (из него будут взяты теги), пропустите или опишите каждое фото отдельно
 
 🔍 Поиск фото:
 1. Нажмите "Найти фотографию"
-2. Введите тег для поиска
-3. Получите все фото с этим тегом
+2. Введите теги для поиска: «море закат», «кот | собака», «море -люди»
+3. Получите все подходящие фото
+   Команда /tags или кнопка «Мои тэги» покажет все ваши тэги
+   Кнопка «📝 Искать по описанию» ищет по словам и фразам из описаний
+4. Под результатами есть кнопки «✏️ Изменить», «🗑 Удалить» и «📁 В альбом»
+
+📁 Альбомы:
+• Кнопка «Мои альбомы» или команда /albums — создать, переименовать, удалить и посмотреть альбомы
+• Добавить фото в альбом можно сразу после загрузки или из результатов поиска
+
+🔗 Общий доступ:
+• /share <тэг> [дней] — ссылка, открывающая другому человеку фото с тэгом
+• /shares — ваши ссылки (их можно отозвать) и тэги, которыми поделились с вами
+
+📦 Экспорт:
+• /export — ZIP-архив со всеми фото и описаниями в manifest.json и manifest.csv
+• Пришлите отредактированный manifest.csv или manifest.json файлом, чтобы обновить описания и тэги сразу у многих фото
+
+👥 Группы:
+• Добавьте бота в группу — у группы будет своя библиотека фото
+• /save [тэги] ответом на фото сохранит его, /find <запрос> найдёт фото группы
 
 💡 Советы:
 • Используйте простые слова как теги
-• Одно описание применится ко всем фото в пачке
-• Дубликаты автоматически пропускаются`
+• Регистр, # и ё не важны: «Море», «море» и «#море» — один тэг
+• Одно описание применится ко всем фото в пачке и добавится к их подписям
+• Дубликаты автоматически пропускаются
+• Результаты поиска показываются по 10 фото, листайте кнопками ◀️ ▶️
+• В любом чате наберите @имя_бота и тэг, чтобы отправить своё фото`
 )
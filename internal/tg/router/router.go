@@ -10,6 +10,20 @@ import (
 	tele "gopkg.in/telebot.v4"
 )
 
+// metricRoutes are the commands and reply buttons reported as routes of
+// their own in the metrics.
+var metricRoutes = []string{
+	"/help", "/find", "/save", "/start", "/info", "/tags", "/albums", "/share", "/shares", "/export",
+	keyboard.BtnUploadPhoto.Text,
+	keyboard.BtnSearchPhoto.Text,
+	keyboard.BtnMyTags.Text,
+	keyboard.BtnMyCollections.Text,
+	keyboard.BtnAddDescription.Text,
+	keyboard.BtnSkipDescription.Text,
+	keyboard.BtnDescribeEach.Text,
+	keyboard.BtnFinishUpload.Text,
+}
+
 type Router struct {
 	handler *handler.Handler
 }
@@ -17,30 +31,85 @@ type Router struct {
 func New(b *tele.Bot, h *handler.Handler, rateLimiter *middleware.RateLimiter) *Router {
 	r := &Router{handler: h}
 
+	b.Use(middleware.Metrics(metricRoutes...))
 	b.Use(rateLimiter.Middleware())
 
-	b.Handle("/start", h.Reg.HandleRegister)
-	b.Handle("/help", h.Help.HandleHelp)
-	b.Handle("/info", h.Info.HandleInfo)
-
-	b.Handle(&keyboard.BtnUploadPhoto, h.Upload.HandleUploadStart)
-	b.Handle(&keyboard.BtnFinishUpload, h.Upload.HandleFinishUpload)
-	b.Handle(&keyboard.BtnAddDescription, h.Upload.HandleAddDescription)
-	b.Handle(&keyboard.BtnSkipDescription, h.Upload.HandleSkipDescription)
-
-	b.Handle(&keyboard.BtnSearchPhoto, h.Search.HandleSearchStart)
+	b.Handle("/help", r.handleHelp)
+	b.Handle("/find", h.Search.HandleFind)
+	b.Handle(&keyboard.BtnSearchPrev, h.Search.HandleSearchPage)
+	b.Handle(&keyboard.BtnSearchNext, h.Search.HandleSearchPage)
+	b.Handle(&keyboard.BtnSearchPage, h.Search.HandleSearchPageInfo)
+
+	g := b.Group()
+	g.Use(groupOnly)
+	g.Handle("/save", h.Group.HandleSave)
+
+	// Everything else works with the sender's own library and reply
+	// keyboards, so it is only available in private chats.
+	p := b.Group()
+	p.Use(privateOnly)
+
+	p.Handle("/start", h.Reg.HandleRegister)
+	p.Handle("/info", h.Info.HandleInfo)
+	p.Handle("/tags", h.Search.HandleTags)
+	p.Handle("/albums", h.Collection.HandleCollections)
+	p.Handle("/share", h.Share.HandleShare)
+	p.Handle("/shares", h.Share.HandleShares)
+	p.Handle("/export", h.Export.HandleExport)
+	p.Handle(tele.OnDocument, h.Import.HandleDocument)
+
+	p.Handle(&keyboard.BtnUploadPhoto, h.Upload.HandleUploadStart)
+	p.Handle(&keyboard.BtnFinishUpload, h.Upload.HandleFinishUpload)
+	p.Handle(&keyboard.BtnAddDescription, h.Upload.HandleAddDescription)
+	p.Handle(&keyboard.BtnSkipDescription, h.Upload.HandleSkipDescription)
+	p.Handle(&keyboard.BtnDescribeEach, h.Upload.HandleDescribeEachStart)
+	p.Handle(&keyboard.BtnDescribeBack, h.Upload.HandleDescribeBack)
+	p.Handle(&keyboard.BtnDescribeSkip, h.Upload.HandleDescribeSkip)
+
+	p.Handle(&keyboard.BtnSearchPhoto, h.Search.HandleSearchStart)
+	p.Handle(&keyboard.BtnSearchByTags, h.Search.HandleSearchModeTags)
+	p.Handle(&keyboard.BtnSearchByText, h.Search.HandleSearchModeText)
+	p.Handle(&keyboard.BtnMyTags, h.Search.HandleTags)
+	p.Handle(&keyboard.BtnTagSearch, h.Search.HandleTagSearch)
+
+	p.Handle(&keyboard.BtnDeletePhoto, h.Photo.HandleDeleteRequest)
+	p.Handle(&keyboard.BtnConfirmDelete, h.Photo.HandleDeleteConfirm)
+	p.Handle(&keyboard.BtnCancelDelete, h.Photo.HandleDeleteCancel)
+	p.Handle(&keyboard.BtnEditPhoto, h.Photo.HandleEditStart)
+	p.Handle(&keyboard.BtnCancelEdit, h.Photo.HandleEditCancel)
+
+	p.Handle(&keyboard.BtnMyCollections, h.Collection.HandleCollections)
+	p.Handle(&keyboard.BtnCollectionOpen, h.Collection.HandleCollectionOpen)
+	p.Handle(&keyboard.BtnCollectionNew, h.Collection.HandleCollectionNew)
+	p.Handle(&keyboard.BtnCollectionShow, h.Search.HandleCollectionShow)
+	p.Handle(&keyboard.BtnCollectionRename, h.Collection.HandleCollectionRename)
+	p.Handle(&keyboard.BtnCollectionDelete, h.Collection.HandleCollectionDelete)
+	p.Handle(&keyboard.BtnCollectionDeleteYes, h.Collection.HandleCollectionDeleteConfirm)
+	p.Handle(&keyboard.BtnCollectionDeleteNo, h.Collection.HandleCollectionDeleteCancel)
+	p.Handle(&keyboard.BtnCollectionPickPhoto, h.Collection.HandlePhotoPick)
+	p.Handle(&keyboard.BtnCollectionAddPhoto, h.Collection.HandleAddPhoto)
+	p.Handle(&keyboard.BtnCollectionAddUpload, h.Upload.HandleAddToCollection)
+	p.Handle(&keyboard.BtnCollectionCancelPick, h.Collection.HandlePickCancel)
+
+	p.Handle(&keyboard.BtnShareRevoke, h.Share.HandleShareRevoke)
+	p.Handle(&keyboard.BtnShareOpen, h.Search.HandleSharedShow)
+
+	p.Handle(&keyboard.BtnImportApply, h.Import.HandleApply)
+	p.Handle(&keyboard.BtnImportCancel, h.Import.HandleCancel)
+
+	b.Handle(tele.OnQuery, h.Search.HandleInlineQuery)
 
 	b.Handle(tele.OnText, r.handleText)
 	b.Handle(tele.OnPhoto, r.handlePhoto)
 
-	logx.Info("router initialized with rate limiting")
+	logx.Info("router initialized with rate limiting and metrics")
 
 	return r
 }
 
 func (r *Router) handleText(c tele.Context) error {
 	sender := c.Sender()
-	if sender == nil {
+	if sender == nil || !isPrivate(c) {
 		return nil
 	}
 
@@ -51,6 +120,14 @@ func (r *Router) handleText(c tele.Context) error {
 		return nil
 	}
 
+	if r.handler.Photo.IsEditingSession(userID) {
+		return r.handler.Photo.HandleEditText(c)
+	}
+
+	if r.handler.Collection.IsNamingSession(userID) {
+		return r.handler.Collection.HandleNameText(c)
+	}
+
 	if r.handler.Upload.IsUploadingSession(userID) {
 		return r.handler.Upload.HandleText(c)
 	}
@@ -68,6 +145,10 @@ func (r *Router) handlePhoto(c tele.Context) error {
 		return nil
 	}
 
+	if !isPrivate(c) {
+		return r.handler.Group.HandlePhoto(c)
+	}
+
 	userID := sender.ID
 
 	if r.handler.Upload.IsUploadingSession(userID) {
@@ -76,3 +157,33 @@ func (r *Router) handlePhoto(c tele.Context) error {
 
 	return message.SendWithEmoji(c, message.EmojiUseButtons, message.MsgUseButtons, keyboard.MainMenu)
 }
+
+func (r *Router) handleHelp(c tele.Context) error {
+	if !isPrivate(c) {
+		return r.handler.Group.HandleHelp(c)
+	}
+	return r.handler.Help.HandleHelp(c)
+}
+
+func isPrivate(c tele.Context) bool {
+	chat := c.Chat()
+	return chat == nil || chat.Type == tele.ChatPrivate
+}
+
+func privateOnly(next tele.HandlerFunc) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		if !isPrivate(c) {
+			return nil
+		}
+		return next(c)
+	}
+}
+
+func groupOnly(next tele.HandlerFunc) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		if isPrivate(c) {
+			return nil
+		}
+		return next(c)
+	}
+}
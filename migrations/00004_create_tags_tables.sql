@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS tags (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS photo_tags (
+    photo_id BIGINT NOT NULL REFERENCES photos(id) ON DELETE CASCADE,
+    tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (photo_id, tag_id)
+);
+
+CREATE INDEX idx_photo_tags_tag_id ON photo_tags(tag_id);
+
+INSERT INTO tags (user_id, name)
+SELECT DISTINCT p.user_id, u.name
+FROM photos p, unnest(p.tags) AS u(name)
+ON CONFLICT (user_id, name) DO NOTHING;
+
+INSERT INTO photo_tags (photo_id, tag_id)
+SELECT DISTINCT p.id, t.id
+FROM photos p
+CROSS JOIN LATERAL unnest(p.tags) AS u(name)
+JOIN tags t ON t.user_id = p.user_id AND t.name = u.name
+ON CONFLICT DO NOTHING;
+
+DROP INDEX IF EXISTS idx_photos_tags;
+ALTER TABLE photos DROP COLUMN IF EXISTS tags;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE photos ADD COLUMN IF NOT EXISTS tags TEXT[];
+
+UPDATE photos p
+SET tags = ARRAY(
+    SELECT t.name
+    FROM photo_tags pt
+    JOIN tags t ON t.id = pt.tag_id
+    WHERE pt.photo_id = p.id
+    ORDER BY t.name
+);
+
+CREATE INDEX IF NOT EXISTS idx_photos_tags ON photos USING GIN(tags);
+
+DROP TABLE IF EXISTS photo_tags;
+DROP TABLE IF EXISTS tags;
+-- +goose StatementEnd
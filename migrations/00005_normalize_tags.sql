@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Same pipeline as validator.NormalizeTag: NFC, no leading "#", lowercase, ё -> е.
+CREATE TEMP TABLE tag_normalization ON COMMIT DROP AS
+SELECT id, user_id, name,
+    translate(lower(ltrim(normalize(name, NFC), '#')), 'ё', 'е') AS normalized
+FROM tags;
+
+DELETE FROM tag_normalization WHERE normalized = name;
+
+INSERT INTO tags (user_id, name)
+SELECT DISTINCT user_id, normalized
+FROM tag_normalization
+WHERE normalized <> ''
+ON CONFLICT (user_id, name) DO NOTHING;
+
+INSERT INTO photo_tags (photo_id, tag_id)
+SELECT pt.photo_id, t.id
+FROM photo_tags pt
+JOIN tag_normalization n ON n.id = pt.tag_id
+JOIN tags t ON t.user_id = n.user_id AND t.name = n.normalized
+ON CONFLICT DO NOTHING;
+
+DELETE FROM tags WHERE id IN (SELECT id FROM tag_normalization);
+-- +goose StatementEnd
+
+-- +goose Down
+-- Normalization merges tags and cannot be undone.
+SELECT 1;
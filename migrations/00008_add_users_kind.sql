@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A group chat owns its shared library the same way a user owns theirs: it is
+-- stored as a users row of kind 'group' with the chat id as telegram_id and
+-- the chat title as username.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'private';
+ALTER TABLE users ADD CONSTRAINT users_kind_check CHECK (kind IN ('private', 'group'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM users WHERE kind = 'group';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_kind_check;
+ALTER TABLE users DROP COLUMN IF EXISTS kind;
+-- +goose StatementEnd
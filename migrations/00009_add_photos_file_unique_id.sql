@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- file_id changes every time Telegram delivers the same picture, file_unique_id
+-- does not. It cannot be derived from file_id in SQL, so existing rows keep
+-- NULL until the archive worker looks it up through getFile.
+ALTER TABLE photos ADD COLUMN file_unique_id VARCHAR(255);
+
+CREATE INDEX idx_photos_file_unique_id ON photos(file_unique_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_photos_file_unique_id;
+ALTER TABLE photos DROP COLUMN IF EXISTS file_unique_id;
+-- +goose StatementEnd
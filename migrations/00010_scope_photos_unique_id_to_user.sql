@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Merge copies of the same picture a user saved more than once into the
+-- oldest one before making file_unique_id unique per user.
+CREATE TEMP TABLE photo_duplicates ON COMMIT DROP AS
+SELECT id, keep_id
+FROM (
+    SELECT id, first_value(id) OVER (PARTITION BY user_id, file_unique_id ORDER BY created_at, id) AS keep_id
+    FROM photos
+    WHERE file_unique_id IS NOT NULL
+) d
+WHERE id <> keep_id;
+
+INSERT INTO photo_tags (photo_id, tag_id)
+SELECT d.keep_id, pt.tag_id
+FROM photo_tags pt
+JOIN photo_duplicates d ON d.id = pt.photo_id
+ON CONFLICT DO NOTHING;
+
+INSERT INTO collection_photos (collection_id, photo_id, added_at)
+SELECT cp.collection_id, d.keep_id, cp.added_at
+FROM collection_photos cp
+JOIN photo_duplicates d ON d.id = cp.photo_id
+ON CONFLICT DO NOTHING;
+
+DELETE FROM photos WHERE id IN (SELECT id FROM photo_duplicates);
+
+DROP INDEX IF EXISTS idx_photos_file_unique_id;
+CREATE UNIQUE INDEX idx_photos_user_file_unique_id ON photos(user_id, file_unique_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_photos_user_file_unique_id;
+CREATE INDEX IF NOT EXISTS idx_photos_file_unique_id ON photos(file_unique_id);
+-- +goose StatementEnd
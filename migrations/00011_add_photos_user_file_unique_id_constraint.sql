@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Photo inserts rely on this constraint in ON CONFLICT to stay idempotent.
+-- users.telegram_id is UNIQUE since the users table was created.
+ALTER TABLE photos
+    ADD CONSTRAINT photos_user_id_file_unique_id_key
+    UNIQUE USING INDEX idx_photos_user_file_unique_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE photos DROP CONSTRAINT IF EXISTS photos_user_id_file_unique_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_photos_user_file_unique_id ON photos(user_id, file_unique_id);
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE photos ADD COLUMN storage_key TEXT;
+ALTER TABLE photos ADD COLUMN checksum VARCHAR(64);
+
+CREATE INDEX idx_photos_unarchived ON photos(id) WHERE storage_key IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_photos_unarchived;
+ALTER TABLE photos DROP COLUMN IF EXISTS checksum;
+ALTER TABLE photos DROP COLUMN IF EXISTS storage_key;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The archive worker counts getFile failures of a photo's file_id so that a
+-- file_id Telegram no longer serves is retried less and less often instead of
+-- on every sweep.
+ALTER TABLE photos ADD COLUMN file_failures INT NOT NULL DEFAULT 0;
+ALTER TABLE photos ADD COLUMN file_failed_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE photos DROP COLUMN IF EXISTS file_failed_at;
+ALTER TABLE photos DROP COLUMN IF EXISTS file_failures;
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL migrations so they do not depend on the
+// working directory of the binary.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -16,8 +16,43 @@ const (
 	MaxFileSize         = 20 * 1024 * 1024
 	MaxPhotosPerSession = 30
 	MaxDescriptionLen   = 1000
+	MaxCaptionLen       = 1024
 	MaxTagLen           = 100
 	MaxTagsPerPhoto     = 50
+	SearchPageSize      = 10
+	InlinePageSize      = 20
+	InlineCacheTime     = 30
+	TagSuggestionsLimit = 5
+	TagCloudSize        = 25
+	MaxCollectionName   = 64
+)
+
+const (
+	ShareTokenBytes    = 16
+	SharePayloadPrefix = "share_"
+	MaxShareDays       = 365
+)
+
+const (
+	StorageTimeout       = 60 * time.Second
+	ArchiveQueueSize     = 256
+	ArchiveBatchSize     = 50
+	ArchiveSweepInterval = time.Hour
+	DefaultStorageDriver = "local"
+	DefaultStorageDir    = "data/photos"
+	DefaultS3Region      = "us-east-1"
+)
+
+const (
+	ExportPartSize = 45 * 1024 * 1024
+	ExportTimeout  = 30 * time.Minute
+	ExportPageSize = 100
+)
+
+const (
+	MaxImportFileSize = 2 * 1024 * 1024
+	MaxImportRows     = 5000
+	ImportReportRows  = 10
 )
 
 const (
@@ -26,6 +61,15 @@ const (
 	RateLimitCleanup  = 5 * time.Minute
 )
 
+const (
+	HealthCheckTimeout = 5 * time.Second
+)
+
+const (
+	WebhookReadTimeout = 10 * time.Second
+	MaxWebhookBodySize = 1 << 20
+)
+
 const (
 	SessionTimeout         = 30 * time.Minute
 	SessionCleanupInterval = 10 * time.Minute
@@ -34,7 +78,11 @@ const (
 const (
 	ShutdownTimeout       = 30 * time.Second
 	BotPollerTimeout      = 10 * time.Second
+	BotClientTimeout      = time.Minute
+	DefaultBotMode        = "polling"
+	DefaultWebhookListen  = ":8443"
 	DefaultRequestTimeout = 15 * time.Second
+	DefaultTagMode        = "words"
 )
 
 const (
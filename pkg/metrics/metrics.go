@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "picstags"
+
+const (
+	UploadSaved     = "saved"
+	UploadDuplicate = "duplicate"
+	UploadFailed    = "failed"
+
+	SearchHits      = "hits"
+	SearchNoResults = "no_results"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	RouteRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "route_requests_total",
+		Help:      "Updates handled per route and outcome: ok, error or rate_limited.",
+	}, []string{"route", "status"})
+
+	RouteDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "route_duration_seconds",
+		Help:      "Time spent handling an update per route.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"route"})
+
+	UploadedPhotos = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_photos_total",
+		Help:      "Photos passed to the upload service by result.",
+	}, []string{"result"})
+
+	Searches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "searches_total",
+		Help:      "Searches by kind and result. Further pages are not counted.",
+	}, []string{"kind", "result"})
+
+	RateLimitRejections = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Updates dropped by the rate limiter.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RouteRequests,
+		RouteDuration,
+		UploadedPhotos,
+		Searches,
+		RateLimitRejections,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegisterSessions reports the number of active sessions of the given kind,
+// read from count on every scrape.
+func RegisterSessions(kind string, count func() int) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "active_sessions",
+		Help:        "Sessions currently kept in memory by kind.",
+		ConstLabels: prometheus.Labels{"kind": kind},
+	}, func() float64 {
+		return float64(count())
+	}))
+}
+
+// Register adds a collector, such as the Postgres pool one, to the registry.
+func Register(c prometheus.Collector) {
+	registry.MustRegister(c)
+}
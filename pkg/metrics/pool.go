@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector exports pgxpool.Stat() on every scrape.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired        *prometheus.Desc
+	idle            *prometheus.Desc
+	constructing    *prometheus.Desc
+	total           *prometheus.Desc
+	max             *prometheus.Desc
+	acquires        *prometheus.Desc
+	acquireDuration *prometheus.Desc
+	emptyAcquires   *prometheus.Desc
+	canceled        *prometheus.Desc
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgxpool", name), help, nil, nil)
+	}
+
+	pc := &poolCollector{}
+
+	pc.pool = pool
+	pc.acquired = desc("acquired_conns", "Connections currently in use.")
+	pc.idle = desc("idle_conns", "Idle connections in the pool.")
+	pc.constructing = desc("constructing_conns", "Connections being established.")
+	pc.total = desc("total_conns", "All connections in the pool.")
+	pc.max = desc("max_conns", "Maximum size of the pool.")
+	pc.acquires = desc("acquires_total", "Successful connection acquires.")
+	pc.acquireDuration = desc("acquire_duration_seconds_total", "Time spent waiting for a connection.")
+	pc.emptyAcquires = desc("empty_acquires_total", "Acquires that had to wait for a connection.")
+	pc.canceled = desc("canceled_acquires_total", "Acquires canceled by their context.")
+
+	return pc
+}
+
+func (pc *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pc.acquired
+	ch <- pc.idle
+	ch <- pc.constructing
+	ch <- pc.total
+	ch <- pc.max
+	ch <- pc.acquires
+	ch <- pc.acquireDuration
+	ch <- pc.emptyAcquires
+	ch <- pc.canceled
+}
+
+func (pc *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := pc.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(pc.acquired, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(pc.idle, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(pc.constructing, prometheus.GaugeValue, float64(s.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(pc.total, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(pc.max, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(pc.acquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(pc.acquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(pc.emptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(pc.canceled, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+}
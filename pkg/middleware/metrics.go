@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"picstagsbot/pkg/metrics"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// rateLimitedKey marks a context whose update the rate limiter rejected, so
+// that Metrics does not count it as handled.
+const rateLimitedKey = "rate_limited"
+
+// Metrics counts handled updates and their latency per route. Only the given
+// commands and reply button texts get their own route label so that users
+// typing arbitrary text cannot blow up the number of series; inline buttons
+// are labelled by their unique name, which telebot only routes for
+// registered buttons.
+func Metrics(routes ...string) tele.MiddlewareFunc {
+	known := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		known[route] = true
+	}
+
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			route := routeOf(c, known)
+			start := time.Now()
+
+			err := next(c)
+
+			status := "ok"
+			switch {
+			case c.Get(rateLimitedKey) == true:
+				status = "rate_limited"
+			case err != nil:
+				status = "error"
+			}
+			metrics.RouteRequests.WithLabelValues(route, status).Inc()
+			metrics.RouteDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+
+			return err
+		}
+	}
+}
+
+func routeOf(c tele.Context, known map[string]bool) string {
+	if cb := c.Callback(); cb != nil {
+		if cb.Unique != "" {
+			return "btn:" + cb.Unique
+		}
+		return "callback"
+	}
+
+	if c.Query() != nil {
+		return "inline_query"
+	}
+
+	msg := c.Message()
+	if msg == nil {
+		return "other"
+	}
+
+	switch {
+	case msg.Photo != nil:
+		return "photo"
+	case msg.Document != nil:
+		return "document"
+	case strings.HasPrefix(msg.Text, "/"):
+		cmd, _, _ := strings.Cut(strings.Fields(msg.Text)[0], "@")
+		if known[cmd] {
+			return cmd
+		}
+		return "command"
+	case known[msg.Text]:
+		return msg.Text
+	case msg.Text != "":
+		return "text"
+	default:
+		return "other"
+	}
+}
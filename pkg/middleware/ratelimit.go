@@ -3,6 +3,7 @@ package middleware
 import (
 	"picstagsbot/pkg/constants"
 	"picstagsbot/pkg/logx"
+	"picstagsbot/pkg/metrics"
 	"sync"
 	"time"
 
@@ -36,10 +37,22 @@ func NewRateLimiter(requests int, window time.Duration) *RateLimiter {
 func (rl *RateLimiter) Middleware() tele.MiddlewareFunc {
 	return func(next tele.HandlerFunc) tele.HandlerFunc {
 		return func(c tele.Context) error {
-			userID := c.Sender().ID
+			sender := c.Sender()
+			if sender == nil {
+				return nil
+			}
+
+			userID := sender.ID
 
 			if !rl.Allow(userID) {
 				logx.Warn("rate limit exceeded", "telegram_id", userID)
+				metrics.RateLimitRejections.Inc()
+				c.Set(rateLimitedKey, true)
+				// Photos in groups are indexed silently, so a busy group
+				// must not be flooded with warnings either.
+				if chat := c.Chat(); chat != nil && chat.Type != tele.ChatPrivate {
+					return nil
+				}
 				return c.Send("Слишком много запросов. Пожалуйста, подождите немного.")
 			}
 
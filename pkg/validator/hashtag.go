@@ -0,0 +1,89 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type TagMode string
+
+const (
+	// TagModeWords turns every word of the description into a tag.
+	TagModeWords TagMode = "words"
+	// TagModeHashtags only takes #hashtags as tags and keeps the rest of the
+	// description as free text.
+	TagModeHashtags TagMode = "hashtags"
+)
+
+var (
+	wordRegex    = regexp.MustCompile(`\S+`)
+	hashtagRegex = regexp.MustCompile(`#+[\p{L}\p{N}_-]*`)
+	spacesRegex  = regexp.MustCompile(`[ \t]+`)
+)
+
+func ParseTagMode(s string) (TagMode, error) {
+	switch mode := TagMode(s); mode {
+	case TagModeWords, TagModeHashtags:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown tag mode: %s", s)
+}
+
+// ParseDescription validates the description and derives its tags according
+// to the mode. It returns the text that should be stored as the description:
+// the sanitized description itself in words mode, and the description without
+// hashtags in hashtags mode.
+func ParseDescription(description string, mode TagMode) (string, []string, error) {
+	if mode != TagModeHashtags {
+		tags, err := ValidateAndParseTags(description)
+		if err != nil {
+			return "", nil, err
+		}
+		return SanitizeString(description), tags, nil
+	}
+
+	if err := ValidateDescription(description); err != nil {
+		return "", nil, err
+	}
+
+	var hashtags []string
+	for _, tok := range Tokenize(description) {
+		if strings.HasPrefix(tok, "#") {
+			hashtags = append(hashtags, tok)
+		}
+	}
+
+	tags := NormalizeTags(hashtags)
+	if err := ValidateTags(tags); err != nil {
+		return "", nil, err
+	}
+
+	return stripHashtags(SanitizeString(description)), tags, nil
+}
+
+// stripHashtags removes the hashtags Tokenize turns into tags. Any other "#"
+// text, like "C#" or a hashtag in a script tags cannot use, stays in the
+// description.
+func stripHashtags(s string) string {
+	s = wordRegex.ReplaceAllStringFunc(norm.NFC.String(s), func(word string) string {
+		if isURL(word) {
+			return word
+		}
+		return hashtagRegex.ReplaceAllStringFunc(word, func(hashtag string) string {
+			if strings.HasPrefix(cleanToken(hashtag), "#") {
+				return ""
+			}
+			return hashtag
+		})
+	})
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spacesRegex.ReplaceAllString(line, " "))
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
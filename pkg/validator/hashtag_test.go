@@ -0,0 +1,40 @@
+package validator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseDescriptionHashtags(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		description string
+		tags        []string
+	}{
+		{"hashtags stripped", "Кот на диване #кот #Диван", "Кот на диване", []string{"кот", "диван"}},
+		{"hashtag inside text", "мой#кот спит", "мой спит", []string{"кот"}},
+		{"edge hyphens", "#-кот- дома", "дома", []string{"кот"}},
+		{"foreign hashtag kept", "Утро в #café", "Утро в #café", nil},
+		{"decomposed foreign hashtag kept", "Утро в #cafe\u0301", "Утро в #café", nil},
+		{"sharp suffix kept", "Пишу на C# #код", "Пишу на C#", []string{"код"}},
+		{"numbers kept", "Номер #42 #дом", "Номер #42", []string{"дом"}},
+		{"urls kept", "https://example.com/#кот #кот", "https://example.com/#кот", []string{"кот"}},
+		{"lines kept", "#кот первая\nвторая #пёс", "первая\nвторая", []string{"кот", "пес"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			description, tags, err := ParseDescription(tt.text, TagModeHashtags)
+			if err != nil {
+				t.Fatalf("ParseDescription(%q): %v", tt.text, err)
+			}
+			if description != tt.description {
+				t.Errorf("description = %q, want %q", description, tt.description)
+			}
+			if !slices.Equal(tags, tt.tags) {
+				t.Errorf("tags = %q, want %q", tags, tt.tags)
+			}
+		})
+	}
+}
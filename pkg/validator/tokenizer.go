@@ -0,0 +1,76 @@
+package validator
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Tokenize splits a free-form description into tag candidates. Punctuation
+// separates words, URLs, tokens made only of digits and words in scripts
+// constants.TagPattern does not allow are skipped, and a leading "#" is kept
+// so hashtags can be told apart from plain words.
+func Tokenize(text string) []string {
+	var tokens []string
+
+	for _, word := range strings.Fields(norm.NFC.String(text)) {
+		if isURL(word) {
+			continue
+		}
+
+		var current strings.Builder
+		flush := func() {
+			if tok := cleanToken(current.String()); tok != "" {
+				tokens = append(tokens, tok)
+			}
+			current.Reset()
+		}
+
+		for _, r := range word {
+			switch {
+			case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-':
+				current.WriteRune(r)
+			case r == '#':
+				if strings.Trim(current.String(), "#") != "" {
+					flush()
+				}
+				current.WriteRune(r)
+			default:
+				flush()
+			}
+		}
+		flush()
+	}
+
+	return tokens
+}
+
+func cleanToken(tok string) string {
+	body := strings.TrimLeft(tok, "#")
+	prefix := tok[:len(tok)-len(body)]
+
+	body = strings.Trim(body, "-")
+	if body == "" || isNumeric(body) || !tagRegex.MatchString(body) {
+		return ""
+	}
+
+	if prefix != "" {
+		return "#" + body
+	}
+	return body
+}
+
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isURL(word string) bool {
+	lower := strings.ToLower(word)
+	return strings.Contains(lower, "://") || strings.HasPrefix(lower, "www.")
+}
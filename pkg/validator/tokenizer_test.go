@@ -0,0 +1,47 @@
+package validator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"plain words", "кот на диване", []string{"кот", "на", "диване"}},
+		{"punctuation separates", "кот,собака;(попугай)!", []string{"кот", "собака", "попугай"}},
+		{"hyphen and underscore kept", "new-york big_city", []string{"new-york", "big_city"}},
+		{"edge hyphens trimmed", "-кот- --", []string{"кот"}},
+		{"hashtags kept", "#кот и#пёс", []string{"#кот", "и", "#пёс"}},
+		{"urls skipped", "смотри https://example.com/a?b=c и www.example.com", []string{"смотри", "и"}},
+		{"numbers only skipped", "2024 12.05 отпуск2024", []string{"отпуск2024"}},
+		{"latin accents skipped", "café au lait", []string{"au", "lait"}},
+		{"ukrainian letters skipped", "їжак і кіт", nil},
+		{"greek skipped", "γάτα cat", []string{"cat"}},
+		{"decomposed cyrillic composed", "и\u0306од", []string{"йод"}},
+		{"empty", "  ", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tokenize(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAndParseTagsSkipsForeignWords(t *testing.T) {
+	tags, err := ValidateAndParseTags("Café у моря, їжак")
+	if err != nil {
+		t.Fatalf("ValidateAndParseTags returned error: %v", err)
+	}
+
+	want := []string{"у", "моря"}
+	if !slices.Equal(tags, want) {
+		t.Errorf("tags = %q, want %q", tags, want)
+	}
+}
@@ -1,30 +1,42 @@
 package validator
 
 import (
+	"errors"
 	"fmt"
 	"picstagsbot/pkg/constants"
 	"regexp"
 	"strings"
 	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 var tagRegex = regexp.MustCompile(constants.TagPattern)
 
+// Errors wrapped by ValidateTag, so that callers can explain to the user
+// what is wrong with a tag.
+var (
+	ErrTagEmpty        = errors.New("tag cannot be empty")
+	ErrTagTooShort     = errors.New("tag is too short")
+	ErrTagTooLong      = errors.New("tag is too long")
+	ErrTagInvalidChars = errors.New("tag contains invalid characters")
+)
+
 func ValidateTag(tag string) error {
 	if tag == "" {
-		return fmt.Errorf("tag cannot be empty")
+		return ErrTagEmpty
 	}
 
 	if utf8.RuneCountInString(tag) < constants.MinTagLength {
-		return fmt.Errorf("tag is too short: minimum %d characters", constants.MinTagLength)
+		return fmt.Errorf("%w: minimum %d characters", ErrTagTooShort, constants.MinTagLength)
 	}
 
 	if utf8.RuneCountInString(tag) > constants.MaxTagLen {
-		return fmt.Errorf("tag is too long: maximum %d characters", constants.MaxTagLen)
+		return fmt.Errorf("%w: maximum %d characters", ErrTagTooLong, constants.MaxTagLen)
 	}
 
 	if !tagRegex.MatchString(tag) {
-		return fmt.Errorf("tag contains invalid characters: only letters, numbers, underscore and hyphen are allowed")
+		return fmt.Errorf("%w: only letters, numbers, underscore and hyphen are allowed", ErrTagInvalidChars)
 	}
 
 	return nil
@@ -61,7 +73,7 @@ func ValidateAndParseTags(description string) ([]string, error) {
 		return nil, err
 	}
 
-	tags := strings.Fields(description)
+	tags := NormalizeTags(Tokenize(description))
 	if err := ValidateTags(tags); err != nil {
 		return nil, err
 	}
@@ -69,6 +81,52 @@ func ValidateAndParseTags(description string) ([]string, error) {
 	return tags, nil
 }
 
+// NormalizeTag brings a tag to its canonical form: Unicode NFC, no leading
+// "#", lowercase and "ё" folded to "е".
+func NormalizeTag(tag string) string {
+	tag = norm.NFC.String(tag)
+	tag = strings.TrimLeft(tag, "#")
+	tag = strings.ToLower(tag)
+	return strings.ReplaceAll(tag, "ё", "е")
+}
+
+// NormalizeTags normalizes every tag, dropping empty ones and duplicates
+// while keeping the original order.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]struct{}, len(tags))
+	result := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		result = append(result, tag)
+	}
+
+	return result
+}
+
+func ValidateCollectionName(name string) error {
+	if name == "" {
+		return fmt.Errorf("collection name cannot be empty")
+	}
+
+	if utf8.RuneCountInString(name) > constants.MaxCollectionName {
+		return fmt.Errorf("collection name is too long: maximum %d characters", constants.MaxCollectionName)
+	}
+
+	if strings.ContainsAny(name, "\n\r") {
+		return fmt.Errorf("collection name must be a single line")
+	}
+
+	return nil
+}
+
 func ValidateFileSize(size int64) error {
 	if size <= 0 {
 		return fmt.Errorf("file size must be positive")